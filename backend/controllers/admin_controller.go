@@ -7,21 +7,40 @@ import (
 	"strconv"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// GET: /admin/audit-logs?page=1&limit=10&user=&action=&endpoint=&entity_type=&entity_id=&from=&to=&q=
 func GetAuditLogs(c *gin.Context) {
 	var logs []models.AuditLog
 	
 	// Ambil parameter dari URL, contoh: /admin/audit-logs?page=1&limit=10
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	} else if limit > auditListMaxLimit {
+		limit = auditListMaxLimit
+	}
 	offset := (page - 1) * limit
-	
-	database.DB.Order("created_at desc").Limit(limit).Offset(offset).Find(&logs)
+
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	filter.Apply(database.DB.Model(&models.AuditLog{})).Count(&total)
+	filter.Apply(database.DB).Order("created_at desc").Limit(limit).Offset(offset).Find(&logs)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"page":  page,
 		"limit": limit,
+		"total": total,
 		"data":  logs,
 	})
 }
@@ -44,6 +63,7 @@ func UpdateUser(c *gin.Context) {
 		Username string `json:"username"`
 		Role     string `json:"role"`
 		Password string `json:"password"` // Opsional, hanya jika ingin reset
+		Reason   string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -58,16 +78,29 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Update field
+	before := user
 	if input.Username != "" { user.Username = input.Username }
 	if input.Role != "" { user.Role = input.Role }
 	
-	// Jika password diisi, hash ulang
+	// Jika password diisi, hash ulang (di riwayat revisi hanya dicatat bahwa password diganti)
+	extra := []models.FieldChange{}
 	if input.Password != "" {
 		hashed, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 		user.Password = string(hashed)
+		extra = append(extra, models.FieldChange{Field: "password", Before: "***", After: "***"})
 	}
 
-	database.DB.Save(&user)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := database.RecordRevision(tx, "user", user.ID, before, user, currentNIK(c), input.Reason, extra...); err != nil {
+			return err
+		}
+		return tx.Save(&user).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update user"})
+		return
+	}
+	recordAuditChange(c, "UPDATE_USER", "user", user.ID, before, user, input.Reason)
 	c.JSON(http.StatusOK, gin.H{"message": "User berhasil diupdate", "data": user})
 }
 
@@ -78,5 +111,6 @@ func DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus user"})
 		return
 	}
+	recordAudit(c, "DELETE_USER", "#"+id)
 	c.JSON(http.StatusOK, gin.H{"message": "User berhasil dihapus"})
 }
\ No newline at end of file
@@ -0,0 +1,411 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"factory-api/realtime"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mencegah dua evaluasi berjalan bersamaan (ticker + trigger manual)
+var alertEngineMu sync.Mutex
+
+// Hasil evaluasi satu rule terhadap satu mesin
+type ruleResult struct {
+	Triggered bool
+	Value     float64
+	Message   string
+}
+
+// Data mesin yang dibaca sekali per putaran evaluasi
+type machineSnapshot struct {
+	Machine    models.Machine
+	LastHour   *models.ChartSeries // Slot jam terakhir yang sudah lewat (sama dengan chart mesin)
+	LastLWP    *time.Time          // SELESAI LWP terakhir hari ini
+	Schedule   *models.Schedule    // Jadwal yang sedang berlaku
+	OpenRepair *models.RepairTicket
+}
+
+// Helper: Role yang menerima alert di tiap level eskalasi
+func alertLevelRoles(level string) []string {
+	switch level {
+	case "LEADER":
+		return []string{"ADMIN", "LEADER"}
+	case "MANAGER":
+		return []string{"ADMIN", "LEADER", "MANAGER"}
+	}
+	return nil // LINE: semua yang berlangganan proses ini
+}
+
+// Helper: Ambil snapshot data mesin untuk evaluasi rule
+func takeMachineSnapshot(m models.Machine, now time.Time) machineSnapshot {
+	snap := machineSnapshot{Machine: m}
+	today := now.Format("2006-01-02")
+
+	if database.MySQL != nil && now.Hour() > 0 {
+		series, err := machineHourlySeries(today, m.NoMC, now.Hour()-1, now.Hour(), "")
+		if err == nil && len(series) > 0 {
+			snap.LastHour = &series[0]
+		} else if err == nil {
+			snap.LastHour = scheduledEmptyHour(m.NoMC, now)
+		}
+
+		var last *time.Time
+		database.MySQL.Raw(`
+			SELECT MAX(TIMESTAMP(tanggal, SELESAI))
+			FROM vtrx_lwp_prs
+			WHERE noMC = ? AND tanggal = ?
+		`, m.NoMC, today).Scan(&last)
+		snap.LastLWP = last
+	}
+
+	var schedule models.Schedule
+	if err := database.DB.Where("no_mc = ? AND status IN ? AND planned_start <= ? AND planned_end > ?",
+		m.NoMC, []string{"PLANNED", "RUNNING"}, now, now).First(&schedule).Error; err == nil {
+		snap.Schedule = &schedule
+	}
+
+	var ticket models.RepairTicket
+	if err := database.DB.Where("no_mc = ? AND status <> ?", m.NoMC, "CLOSED").First(&ticket).Error; err == nil {
+		snap.OpenRepair = &ticket
+	}
+	return snap
+}
+
+// Helper: Jam terakhir tanpa LWP sama sekali - jika mesin terjadwal di jam itu, target tetap dihitung dengan aktual 0
+func scheduledEmptyHour(noMC string, now time.Time) *models.ChartSeries {
+	hourEnd := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	hourStart := hourEnd.Add(-time.Hour)
+
+	var schedule models.Schedule
+	if err := database.DB.Where("no_mc = ? AND status IN ? AND planned_start < ? AND planned_end > ?",
+		noMC, []string{"PLANNED", "RUNNING"}, hourEnd, hourStart).First(&schedule).Error; err != nil {
+		return nil
+	}
+
+	// Hanya porsi jam yang tercakup jadwal yang ditargetkan
+	start, end := schedule.PlannedStart, schedule.PlannedEnd
+	if start.Before(hourStart) {
+		start = hourStart
+	}
+	if end.After(hourEnd) {
+		end = hourEnd
+	}
+	target := getTargetPerJam(schedule.ItemCode, schedule.MoldCode, noMC, hourStart.Format("2006-01-02")) * end.Sub(start).Hours()
+	if target <= 0 {
+		return nil
+	}
+	return &models.ChartSeries{
+		Label:    hourStart.Format("15:00"),
+		Target:   target,
+		ItemCode: schedule.ItemCode,
+	}
+}
+
+// Helper: Evaluasi satu rule terhadap snapshot mesin
+func evaluateRule(rule models.AlertRule, snap machineSnapshot, now time.Time) ruleResult {
+	switch rule.Type {
+	case "LOW_OUTPUT":
+		h := snap.LastHour
+		if h == nil || h.Target <= 0 {
+			return ruleResult{}
+		}
+		pct := h.Actual / h.Target * 100
+		return ruleResult{
+			Triggered: pct < rule.Threshold,
+			Value:     pct,
+			Message:   fmt.Sprintf("Output jam %s hanya %.0f%% dari target (%.0f / %.0f)", h.Label, pct, h.Actual, h.Target),
+		}
+
+	case "HIGH_NG":
+		h := snap.LastHour
+		if h == nil || h.Actual <= 0 {
+			return ruleResult{}
+		}
+		pct := h.ActualNG / h.Actual * 100
+		return ruleResult{
+			Triggered: pct > rule.Threshold,
+			Value:     pct,
+			Message:   fmt.Sprintf("NG rate jam %s %.1f%% (batas %.1f%%)", h.Label, pct, rule.Threshold),
+		}
+
+	case "MACHINE_DOWN":
+		if snap.OpenRepair == nil {
+			return ruleResult{}
+		}
+		minutes := now.Sub(snap.OpenRepair.ReportedAt).Minutes()
+		return ruleResult{
+			Triggered: minutes > rule.Threshold,
+			Value:     minutes,
+			Message:   fmt.Sprintf("Mesin down %.0f menit (tiket #%d)", minutes, snap.OpenRepair.ID),
+		}
+
+	case "NO_LWP":
+		if snap.Schedule == nil || snap.OpenRepair != nil {
+			return ruleResult{}
+		}
+		since := snap.Schedule.PlannedStart
+		if snap.LastLWP != nil && snap.LastLWP.After(since) {
+			since = *snap.LastLWP
+		}
+		minutes := now.Sub(since).Minutes()
+		return ruleResult{
+			Triggered: minutes > rule.Threshold,
+			Value:     minutes,
+			Message:   fmt.Sprintf("Tidak ada LWP selama %.0f menit padahal mesin terjadwal", minutes),
+		}
+	}
+	return ruleResult{}
+}
+
+// Helper: Naikkan level alert yang belum di-acknowledge
+func escalateAlert(alert *models.Alert, rule models.AlertRule, now time.Time) {
+	elapsed := now.Sub(alert.OpenedAt).Minutes()
+	level := alert.Level
+	switch {
+	case elapsed >= float64(rule.EscalateManagerMin):
+		level = "MANAGER"
+	case elapsed >= float64(rule.EscalateLeaderMin):
+		level = "LEADER"
+	}
+	if level == alert.Level {
+		return
+	}
+
+	alert.Level = level
+	alert.EscalatedAt = &now
+	database.DB.Save(alert)
+	publishEvent(realtime.EventAlert, alert.NoMC, gin.H{"action": "escalated", "alert": alert}, alertLevelRoles(level)...)
+
+	// Notifikasi di luar dashboard (email / webhook / chat) sesuai preferensi user
+	notify.NotifyRoles("alert.escalated", alertLevelRoles(level), map[string]interface{}{
+		"no_mc":     alert.NoMC,
+		"level":     level,
+		"message":   alert.Message,
+		"opened_at": alert.OpenedAt.Format("2006-01-02 15:04"),
+	})
+}
+
+// EvaluateAlertRules menjalankan satu putaran evaluasi: buka, eskalasi & resolve alert
+func EvaluateAlertRules() (opened, resolved int) {
+	alertEngineMu.Lock()
+	defer alertEngineMu.Unlock()
+
+	var rules []models.AlertRule
+	database.DB.Where("aktif = ?", true).Find(&rules)
+	if len(rules) == 0 {
+		return 0, 0
+	}
+
+	var machines []models.Machine
+	database.DB.Where("aktif = ?", true).Find(&machines)
+
+	now := time.Now()
+	snapshots := make(map[string]machineSnapshot)
+
+	for _, rule := range rules {
+		for _, m := range machines {
+			if (rule.Proses != "" && rule.Proses != m.Proses) || (rule.NoMC != "" && rule.NoMC != m.NoMC) {
+				continue
+			}
+
+			snap, ok := snapshots[m.NoMC]
+			if !ok {
+				snap = takeMachineSnapshot(m, now)
+				snapshots[m.NoMC] = snap
+			}
+			res := evaluateRule(rule, snap, now)
+
+			var alert models.Alert
+			hasOpen := database.DB.Where("rule_id = ? AND no_mc = ? AND status <> ?", rule.ID, m.NoMC, "RESOLVED").
+				First(&alert).Error == nil
+
+			switch {
+			case res.Triggered && !hasOpen:
+				alert = models.Alert{
+					RuleID:   rule.ID,
+					RuleType: rule.Type,
+					NoMC:     m.NoMC,
+					Proses:   m.Proses,
+					Message:  res.Message,
+					Value:    res.Value,
+					Status:   "OPEN",
+					Level:    "LINE",
+					OpenedAt: now,
+				}
+				database.DB.Create(&alert)
+				opened++
+				publishEvent(realtime.EventAlert, m.NoMC, gin.H{"action": "opened", "alert": alert})
+				escalateAlert(&alert, rule, now)
+
+			case res.Triggered && hasOpen:
+				alert.Value = res.Value
+				alert.Message = res.Message
+				database.DB.Save(&alert)
+				if alert.Status == "OPEN" {
+					escalateAlert(&alert, rule, now)
+				}
+
+			case !res.Triggered && hasOpen:
+				alert.Status = "RESOLVED"
+				alert.ResolvedAt = &now
+				database.DB.Save(&alert)
+				resolved++
+				publishEvent(realtime.EventAlert, m.NoMC, gin.H{"action": "resolved", "alert": alert})
+			}
+		}
+	}
+	return opened, resolved
+}
+
+// StartAlertEngine menjalankan evaluasi rule andon secara berkala (dipanggil dari main sebagai goroutine)
+func StartAlertEngine(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		EvaluateAlertRules()
+	}
+}
+
+// GET: /api/alerts?status=OPEN&no_mc=04A
+func GetAlerts(c *gin.Context) {
+	query := database.DB.Order("opened_at desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status <> ?", "RESOLVED")
+	}
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	if proses := c.Query("proses"); proses != "" {
+		query = query.Where("proses = ?", proses)
+	}
+
+	var alerts []models.Alert
+	query.Limit(200).Find(&alerts)
+	c.JSON(http.StatusOK, gin.H{"total": len(alerts), "data": alerts})
+}
+
+// POST: /api/alerts/:id/acknowledge - Hentikan eskalasi
+func AcknowledgeAlert(c *gin.Context) {
+	var alert models.Alert
+	if err := database.DB.First(&alert, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert tidak ditemukan"})
+		return
+	}
+	if alert.Status != "OPEN" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert sudah " + alert.Status})
+		return
+	}
+
+	now := time.Now()
+	alert.Status = "ACKNOWLEDGED"
+	alert.AcknowledgedBy = currentNIK(c)
+	alert.AcknowledgedAt = &now
+	database.DB.Save(&alert)
+
+	recordAudit(c, "ACK_ALERT", fmt.Sprintf("#%d MC %s: %s", alert.ID, alert.NoMC, alert.Message))
+	publishEvent(realtime.EventAlert, alert.NoMC, gin.H{"action": "acknowledged", "alert": alert})
+	c.JSON(http.StatusOK, gin.H{"message": "Alert di-acknowledge", "data": alert})
+}
+
+// POST: /api/alerts/evaluate - Jalankan evaluasi sekarang (tanpa menunggu ticker)
+func TriggerAlertEvaluation(c *gin.Context) {
+	opened, resolved := EvaluateAlertRules()
+	c.JSON(http.StatusOK, gin.H{"message": "Evaluasi selesai", "opened": opened, "resolved": resolved})
+}
+
+// Struct input rule andon
+type AlertRuleInput struct {
+	Nama               string  `json:"nama" binding:"required"`
+	Type               string  `json:"type" binding:"required,oneof=LOW_OUTPUT HIGH_NG MACHINE_DOWN NO_LWP"`
+	Threshold          float64 `json:"threshold" binding:"required,gt=0"`
+	Proses             string  `json:"proses"`
+	NoMC               string  `json:"no_mc"`
+	EscalateLeaderMin  int     `json:"escalate_leader_min"`
+	EscalateManagerMin int     `json:"escalate_manager_min"`
+	Aktif              *bool   `json:"aktif"`
+}
+
+// GET: /api/alert-rules
+func GetAlertRules(c *gin.Context) {
+	var rules []models.AlertRule
+	database.DB.Order("id asc").Find(&rules)
+	c.JSON(http.StatusOK, gin.H{"total": len(rules), "data": rules})
+}
+
+// POST: /api/alert-rules
+func CreateAlertRule(c *gin.Context) {
+	var input AlertRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if input.EscalateManagerMin <= input.EscalateLeaderMin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "escalate_manager_min harus lebih besar dari escalate_leader_min"})
+		return
+	}
+
+	rule := models.AlertRule{
+		Nama:               input.Nama,
+		Type:               input.Type,
+		Threshold:          input.Threshold,
+		Proses:             input.Proses,
+		NoMC:               input.NoMC,
+		EscalateLeaderMin:  input.EscalateLeaderMin,
+		EscalateManagerMin: input.EscalateManagerMin,
+		Aktif:              input.Aktif == nil || *input.Aktif,
+	}
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan rule"})
+		return
+	}
+	// default:true di GORM mengabaikan nilai false saat Create
+	if !rule.Aktif {
+		database.DB.Model(&rule).Update("aktif", false)
+	}
+
+	recordAudit(c, "CREATE_ALERT_RULE", rule.Nama)
+	c.JSON(http.StatusCreated, gin.H{"message": "Rule andon dibuat", "data": rule})
+}
+
+// PUT: /api/alert-rules/:id
+func UpdateAlertRule(c *gin.Context) {
+	var rule models.AlertRule
+	if err := database.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule tidak ditemukan"})
+		return
+	}
+
+	var input AlertRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if input.EscalateManagerMin <= input.EscalateLeaderMin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "escalate_manager_min harus lebih besar dari escalate_leader_min"})
+		return
+	}
+
+	rule.Nama = input.Nama
+	rule.Type = input.Type
+	rule.Threshold = input.Threshold
+	rule.Proses = input.Proses
+	rule.NoMC = input.NoMC
+	rule.EscalateLeaderMin = input.EscalateLeaderMin
+	rule.EscalateManagerMin = input.EscalateManagerMin
+	if input.Aktif != nil {
+		rule.Aktif = *input.Aktif
+	}
+	database.DB.Save(&rule)
+
+	recordAudit(c, "UPDATE_ALERT_RULE", rule.Nama)
+	c.JSON(http.StatusOK, gin.H{"message": "Rule andon diupdate", "data": rule})
+}
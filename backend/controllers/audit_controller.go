@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper: Isi konteks request (pelaku, role, IP, device, endpoint) ke event audit
+func auditEvent(c *gin.Context, action, details string) database.AuditEvent {
+	device := c.GetHeader("X-Device-ID")
+	if device == "" {
+		device = c.Request.UserAgent()
+	}
+	return database.AuditEvent{
+		Username: currentNIK(c),
+		Role:     currentRole(c),
+		IP:       c.ClientIP(),
+		Device:   device,
+		Method:   c.Request.Method,
+		Endpoint: c.Request.URL.Path,
+		Action:   action,
+		Details:  details,
+	}
+}
+
+// Helper: Tandai response jika audit gagal ditulis (perubahan sudah tersimpan, tapi jejaknya tidak)
+func auditFailed(c *gin.Context, err error) error {
+	if err != nil {
+		c.Header("X-Audit-Status", "FAILED")
+		c.Error(err)
+	}
+	return err
+}
+
+// Helper: Catat aksi user dari handler
+func recordAudit(c *gin.Context, action, details string) error {
+	_, err := database.WriteAudit(auditEvent(c, action, details))
+	return auditFailed(c, err)
+}
+
+// Helper: Catat perubahan data beserta entity & snapshot sebelum/sesudah
+func recordAuditChange(c *gin.Context, action, entityType string, entityID uint, before, after interface{}, details string) error {
+	ev := auditEvent(c, action, details)
+	ev.EntityType = entityType
+	ev.EntityID = fmt.Sprintf("%d", entityID)
+	ev.Before = before
+	ev.After = after
+	_, err := database.WriteAudit(ev)
+	return auditFailed(c, err)
+}
+
+// GET: /admin/audit-logs/verify - Cek rantai hash audit (deteksi baris diubah/dihapus)
+func VerifyAuditLogs(c *gin.Context) {
+	result, err := database.VerifyAuditChain()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memverifikasi audit: " + err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if !result.Valid {
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"data": result})
+}
+
+// Helper: Susun filter audit dari query string (from/to format YYYY-MM-DD, to inklusif)
+func auditFilterFromQuery(c *gin.Context) (database.AuditFilter, error) {
+	filter := database.AuditFilter{
+		Username:   c.Query("user"),
+		Action:     c.Query("action"),
+		Endpoint:   c.Query("endpoint"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Result:     c.Query("result"),
+		Search:     c.Query("q"),
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("format from salah (gunakan YYYY-MM-DD)")
+		}
+		filter.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("format to salah (gunakan YYYY-MM-DD)")
+		}
+		t = t.AddDate(0, 0, 1)
+		filter.To = &t
+	}
+	return filter, nil
+}
+
+// GET: /admin/audit-logs/export?format=csv|json&<filter sama dengan /admin/audit-logs>
+func ExportAuditLogs(c *gin.Context) {
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format harus csv atau json"})
+		return
+	}
+
+	// Ambil satu baris lebih untuk mendeteksi hasil yang melebihi batas (export audit tidak boleh terpotong diam-diam)
+	var logs []models.AuditLog
+	filter.Apply(database.DB).Order("id asc").Limit(auditExportLimit + 1).Find(&logs)
+	if len(logs) > auditExportLimit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Hasil export melebihi %d baris, persempit filter (from / to)", auditExportLimit),
+		})
+		return
+	}
+
+	recordAudit(c, "EXPORT_AUDIT_LOG", fmt.Sprintf("%s, %d baris", format, len(logs)))
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+
+	if format == "json" {
+		c.JSON(http.StatusOK, logs)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "username", "role", "ip", "device", "method", "endpoint",
+		"action", "entity_type", "entity_id", "result", "details", "before", "after", "hash"})
+	for _, l := range logs {
+		w.Write([]string{
+			strconv.FormatUint(uint64(l.ID), 10), l.CreatedAt.Format(time.RFC3339), l.Username, l.Role,
+			l.IP, l.Device, l.Method, l.Endpoint, l.Action, l.EntityType, l.EntityID, l.Result,
+			l.Details, l.Before, l.After, l.Hash,
+		})
+	}
+	w.Flush()
+}
+
+// Batas baris satu kali export
+const auditExportLimit = 50000
+
+// Batas limit per halaman daftar audit log
+const auditListMaxLimit = 500
+
+// Helper: Hari retensi audit log (env AUDIT_RETENTION_DAYS, default 365)
+func auditRetentionDays() int {
+	if v, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && v > 0 {
+		return v
+	}
+	return 365
+}
+
+// Helper: Folder arsip audit (env AUDIT_ARCHIVE_DIR, default archive/audit)
+func auditArchiveDir() string {
+	if v := os.Getenv("AUDIT_ARCHIVE_DIR"); v != "" {
+		return v
+	}
+	return filepath.Join("archive", "audit")
+}
+
+// POST: /admin/audit-logs/archive - Jalankan retention policy sekarang ({"older_than_days": 365})
+func ArchiveAuditLogs(c *gin.Context) {
+	var input struct {
+		OlderThanDays int `json:"older_than_days"`
+	}
+	c.ShouldBindJSON(&input)
+	if input.OlderThanDays <= 0 {
+		input.OlderThanDays = auditRetentionDays()
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -input.OlderThanDays)
+	archive, err := database.ArchiveAuditLogs(cutoff, auditArchiveDir(), currentNIK(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengarsip audit log: " + err.Error()})
+		return
+	}
+	if archive == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Tidak ada audit log yang perlu diarsip"})
+		return
+	}
+
+	recordAudit(c, "ARCHIVE_AUDIT_LOG", fmt.Sprintf("%s (%d baris)", archive.FileName, archive.Rows))
+	c.JSON(http.StatusOK, gin.H{"message": "Audit log diarsip", "data": archive})
+}
+
+// GET: /admin/audit-archives - Daftar file arsip audit
+func GetAuditArchives(c *gin.Context) {
+	var archives []models.AuditArchive
+	database.DB.Order("id desc").Find(&archives)
+	c.JSON(http.StatusOK, gin.H{"data": archives, "retention_days": auditRetentionDays()})
+}
+
+// GET: /admin/audit-archives/:id/download
+func DownloadAuditArchive(c *gin.Context) {
+	var archive models.AuditArchive
+	if err := database.DB.First(&archive, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Arsip tidak ditemukan"})
+		return
+	}
+	c.FileAttachment(filepath.Join(auditArchiveDir(), archive.FileName), archive.FileName)
+}
+
+// StartAuditRetention mengarsip audit log yang melewati masa retensi secara berkala (dipanggil dari main)
+func StartAuditRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		cutoff := time.Now().AddDate(0, 0, -auditRetentionDays())
+		if archive, err := database.ArchiveAuditLogs(cutoff, auditArchiveDir(), "SYSTEM"); err != nil {
+			fmt.Printf("[AUDIT ERROR] Retention gagal: %v\n", err)
+		} else if archive != nil {
+			fmt.Printf("[AUDIT] %d baris diarsip ke %s\n", archive.Rows, archive.FileName)
+		}
+	}
+}
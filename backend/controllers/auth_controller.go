@@ -35,12 +35,14 @@ func Login(c *gin.Context) {
 
 	// 1. Cek ke Database MySQL berdasarkan NIK
 	if err := database.MySQL.Where("nik = ?", input.Username).First(&employee).Error; err != nil {
+		recordLogin(c, input.Username, "", "FAILED", "NIK tidak ditemukan")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "NIK tidak ditemukan"})
 		return
 	}
 
 	// 2. Cek Password (Plain Text sesuai instruksi sebelumnya)
 	if employee.Passw != input.Password {
+		recordLogin(c, input.Username, "", "FAILED", "Password salah")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password salah"})
 		return
 	}
@@ -59,6 +61,7 @@ func Login(c *gin.Context) {
 	})
 
 	tokenString, _ := token.SignedString(jwtKey)
+	recordLogin(c, employee.NIK, userRole, "SUCCESS", employee.Nama)
 
 	fmt.Printf("[LOGIN] User: %s | ID: %d | Role Assigned: %s | Email: %s\n", 
 		employee.Nama, employee.IDEmploy, userRole, employee.EmailAddr)
@@ -128,4 +131,32 @@ func determineRole(idEmploy int) string {
 		// Sisanya dianggap Pressing
 		return "OPERATOR_PRESSING"
 	}
-}
\ No newline at end of file
+}
+// Helper: Ambil NIK user yang sedang login dari context (diisi AuthAndRoleMiddleware)
+func currentNIK(c *gin.Context) string {
+	if val, ok := c.Get("username"); ok {
+		if nik, ok := val.(string); ok {
+			return nik
+		}
+	}
+	return "UNKNOWN_USER"
+}
+
+// Helper: Ambil role user yang sedang login dari context
+func currentRole(c *gin.Context) string {
+	if val, ok := c.Get("userRole"); ok {
+		if role, ok := val.(string); ok {
+			return role
+		}
+	}
+	return ""
+}
+
+// Helper: Catat percobaan login (user belum punya token, jadi NIK & role diisi manual)
+func recordLogin(c *gin.Context, nik, role, result, details string) {
+	ev := auditEvent(c, "LOGIN", details)
+	ev.Username = nik
+	ev.Role = role
+	ev.Result = result
+	database.WriteAudit(ev)
+}
@@ -3,7 +3,10 @@ package controllers
 import (
 	"factory-api/database"
 	"factory-api/models"
+	"factory-api/process"
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +23,21 @@ func isDBConnected(c *gin.Context) bool {
 	return true
 }
 
+// Helper: Rentang jam per shift (dipakai chart mesin & jadwal produksi)
+func shiftHours(shift string) (int, int) {
+	switch shift {
+	case "1":
+		return 0, 8
+	case "2":
+		return 8, 16
+	case "3":
+		return 16, 24
+	default:
+		// If no shift specified, show all 24 hours
+		return 0, 24
+	}
+}
+
 // --- LEVEL 1: MANAGER VIEW (Overview Per Proses) ---
 func GetManagerOverview(c *gin.Context) {
 	if !isDBConnected(c) {
@@ -27,33 +45,50 @@ func GetManagerOverview(c *gin.Context) {
 	}
 
 	tanggal := c.Query("tanggal")
+
+	// ?approved_only=true -> hanya data LWP yang sudah di-approve leader
+	filter, filterArgs := approvedLotFilter(c, tanggal)
+
+	results, err := managerSeries(tanggal, filter, filterArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data manager: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Helper: Target vs aktual per proses untuk satu tanggal (dipakai chart manager & export)
+func managerSeries(tanggal, filter string, filterArgs ...interface{}) ([]models.ChartSeries, error) {
 	var results []models.ChartSeries
 
 	// Rumus: Target = (Durasi Jam) * (Target Qty/Jam)
 	query := `
 		SELECT 
 			t.proses AS label,
-			COALESCE(SUM((TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0) * s.tgtQtyPJam), 0) AS target,
+			COALESCE(SUM((TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0) * COALESCE(om.tgtQtyPJam, o.tgtQtyPJam, s.tgtQtyPJam)), 0) AS target,
 			COALESCE(SUM(t.Total), 0) AS actual,
-			COALESCE(SUM(t.NG), 0) AS actual_ng
-		FROM vtrx_lwp_prs t
-		LEFT JOIN (
-    SELECT itemCode, moldCode, MAX(tgtQtyPJam) AS tgtQtyPJam 
-    FROM v_stdlot 
-    GROUP BY itemCode, moldCode
-) s 
-  ON t.itemCode = s.itemCode COLLATE utf8mb4_unicode_ci 
-  AND t.moldCode = s.moldCode COLLATE utf8mb4_unicode_ci
-		WHERE t.tanggal = ?
+			COALESCE(SUM(t.OK + COALESCE(rw.qty, 0)), 0) AS actual_ok,
+			COALESCE(SUM(t.NG), 0) AS actual_ng,
+			COALESCE(SUM(rw.qty), 0) AS rework_ok
+		FROM %s t
+		%s
+		WHERE t.tanggal = ?%s
 		GROUP BY t.proses
 	`
 
-	if err := database.MySQL.Raw(query, tanggal).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data manager: " + err.Error()})
-		return
+	// Satu query per sumber proses (CUT, PRS, FIN, ...), hasil digabung.
+	// Standar: versi yang berlaku di tanggal tsb, fallback v_stdlot (+ rework OK dari disposisi)
+	for _, def := range process.All() {
+		join, joinArgs := chartJoin(def, tanggal, tanggal)
+		args := append(append(joinArgs, tanggal), filterArgs...)
+		var rows []models.ChartSeries
+		if err := database.MySQL.Raw(fmt.Sprintf(query, def.Source(), join, filter), args...).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("proses %s: %w", def.Code, err)
+		}
+		results = append(results, rows...)
 	}
-
-	c.JSON(http.StatusOK, results)
+	return results, nil
 }
 
 // --- LEVEL 2: LEADER VIEW (Overview Per Mesin) ---
@@ -65,34 +100,50 @@ func GetLeaderProcessView(c *gin.Context) {
 	tanggal := c.Query("tanggal")
 	proses := c.Query("proses")
 
+	filter, filterArgs := approvedLotFilter(c, tanggal)
+
+	results, err := processSeries(tanggal, proses, filter, filterArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data leader: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Helper: Target vs aktual per mesin dalam satu proses (dipakai chart leader & export)
+func processSeries(tanggal, proses, filter string, filterArgs ...interface{}) ([]models.ChartSeries, error) {
 	var results []models.ChartSeries
 
 	// Rumus Konsisten: Target = (Durasi Jam) * (Target Qty/Jam)
 	query := `
 		SELECT 
 			t.noMC AS label,
-			COALESCE(SUM((TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0) * s.tgtQtyPJam), 0) AS target,
+			COALESCE(SUM((TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0) * COALESCE(om.tgtQtyPJam, o.tgtQtyPJam, s.tgtQtyPJam)), 0) AS target,
 			COALESCE(SUM(t.Total), 0) AS actual,
-			COALESCE(SUM(t.NG), 0) AS actual_ng
-		FROM vtrx_lwp_prs t
-		LEFT JOIN (
-			SELECT itemCode, moldCode, MAX(tgtQtyPJam) AS tgtQtyPJam 
-			FROM v_stdlot 
-			GROUP BY itemCode, moldCode
-		) s 
-		ON t.itemCode = s.itemCode COLLATE utf8mb4_unicode_ci 
-		AND t.moldCode = s.moldCode COLLATE utf8mb4_unicode_ci
-		WHERE t.tanggal = ? AND t.proses = ?
+			COALESCE(SUM(t.OK + COALESCE(rw.qty, 0)), 0) AS actual_ok,
+			COALESCE(SUM(t.NG), 0) AS actual_ng,
+			COALESCE(SUM(rw.qty), 0) AS rework_ok
+		FROM %s t
+		%s
+		WHERE t.tanggal = ? AND t.proses = ?%s
 		GROUP BY t.noMC
 		ORDER BY t.noMC
 	`
 
-	if err := database.MySQL.Raw(query, tanggal, proses).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data leader: " + err.Error()})
-		return
+	for _, def := range process.All() {
+		if !def.HasLabel(proses) {
+			continue
+		}
+		join, joinArgs := chartJoin(def, tanggal, tanggal)
+		args := append(append(joinArgs, tanggal, proses), filterArgs...)
+		var rows []models.ChartSeries
+		if err := database.MySQL.Raw(fmt.Sprintf(query, def.Source(), join, filter), args...).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("proses %s: %w", def.Code, err)
+		}
+		results = append(results, rows...)
 	}
-
-	c.JSON(http.StatusOK, results)
+	return results, nil
 }
 
 // --- LEVEL 3: MACHINE DETAIL (Per Jam) WITH SHIFT FILTER ---
@@ -105,26 +156,24 @@ func GetMachineDetail(c *gin.Context) {
 	noMC := c.Query("no_mc")
 	shift := c.Query("shift") // Get shift parameter
 
-	var results []models.ChartSeries
-
 	// Define shift hour ranges
-	var startHour, endHour int
-	switch shift {
-	case "1":
-		startHour = 0
-		endHour = 8
-	case "2":
-		startHour = 8
-		endHour = 16
-	case "3":
-		startHour = 16
-		endHour = 24
-	default:
-		// If no shift specified, show all 24 hours
-		startHour = 0
-		endHour = 24
+	startHour, endHour := shiftHours(shift)
+
+	filter, filterArgs := approvedLotFilter(c, tanggal)
+	results, err := machineHourlySeries(tanggal, noMC, startHour, endHour, filter, filterArgs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil detail mesin: " + err.Error()})
+		return
 	}
 
+	c.JSON(http.StatusOK, results)
+}
+
+// Helper: Target vs aktual per jam untuk satu mesin (dipakai chart mesin & andon).
+// filter adalah kondisi tambahan untuk alias t (misal dari approvedLotFilter).
+func machineHourlySeries(tanggal, noMC string, startHour, endHour int, filter string, filterArgs ...interface{}) ([]models.ChartSeries, error) {
+	var results []models.ChartSeries
+
 	// Updated query with shift filtering
 	query := `
 WITH RECURSIVE 
@@ -135,8 +184,8 @@ jam_master AS (
 ),
 raw_data AS (
     SELECT 
-        t.tanggal, t.nama AS operator, t.MULAI, t.SELESAI,
-        t.itemCode, s.tgtQtyPJam, j.jam_angka,
+        t.tanggal, t.NPK AS npk, t.nama AS operator, t.MULAI, t.SELESAI,
+        t.itemCode, COALESCE(om.tgtQtyPJam, o.tgtQtyPJam, s.tgtQtyPJam) AS tgtQtyPJam, j.jam_angka,
         
         -- Hitung detik overlap di jam ini
         (GREATEST(0, TIME_TO_SEC(TIMEDIFF(LEAST(t.SELESAI, MAKETIME(j.jam_angka + 1, 0, 0)), GREATEST(t.MULAI, MAKETIME(j.jam_angka, 0, 0)))))) AS seconds_in_slot,
@@ -144,41 +193,106 @@ raw_data AS (
         -- Total durasi transaksi asli (untuk pembagi rasio)
         NULLIF(TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)), 0) AS total_duration_sec,
 
-        t.Total, t.OK, t.NG
-    FROM vtrx_lwp_prs t
-    LEFT JOIN (
-    SELECT itemCode, moldCode, MAX(tgtQtyPJam) AS tgtQtyPJam 
-    FROM v_stdlot 
-    GROUP BY itemCode, moldCode
-) s 
-  ON t.itemCode = s.itemCode COLLATE utf8mb4_unicode_ci 
-  AND t.moldCode = s.moldCode COLLATE utf8mb4_unicode_ci
+        t.Total, t.OK, t.NG, COALESCE(rw.qty, 0) AS rework
+    FROM %s t
+    %s
     CROSS JOIN jam_master j
     WHERE 
         t.tanggal = ? 
         AND t.noMC = ? 
         AND t.MULAI < MAKETIME(j.jam_angka + 1, 0, 0) 
-        AND t.SELESAI > MAKETIME(j.jam_angka, 0, 0)
+        AND t.SELESAI > MAKETIME(j.jam_angka, 0, 0)%s
 )
 SELECT 
     CONCAT(LPAD(jam_angka, 2, '0'), ':00') AS label,
     COALESCE(SUM((seconds_in_slot / 3600.0) * tgtQtyPJam), 0) AS target,
     COALESCE(SUM(ROUND(Total * (seconds_in_slot / total_duration_sec),2)), 0)+0 AS actual,
+    COALESCE(SUM(ROUND((OK + rework) * (seconds_in_slot / total_duration_sec),2)), 0)+0 AS actual_ok,
     COALESCE(SUM(ROUND(NG * (seconds_in_slot / total_duration_sec),2)), 0)+0 AS actual_ng,
+    COALESCE(SUM(ROUND(rework * (seconds_in_slot / total_duration_sec),2)), 0)+0 AS rework_ok,
     
     -- UBAH: Sekarang mengambil itemCode, bukan itemName
     COALESCE(GROUP_CONCAT(DISTINCT raw_data.itemCode SEPARATOR ', '), '-') AS item_code,
     
-    CONCAT(COALESCE(MAX(raw_data.itemCode), '-'), ' (', COALESCE(MAX(operator), '-'), ')') AS extra_info
+    COALESCE(MAX(raw_data.itemCode), '-') AS first_item,
+    COALESCE(MAX(npk), '') AS npk,
+    COALESCE(MAX(operator), '') AS operator
 FROM raw_data
 GROUP BY jam_angka
 ORDER BY jam_angka ASC;
 	`
 
-	if err := database.MySQL.Raw(query, startHour, endHour-1, tanggal, noMC).Scan(&results).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil detail mesin: " + err.Error()})
-		return
+	// Operator di-resolve ke nama tm_employ (NPK lama / alias) seperti scorecard
+	type hourlyRow struct {
+		models.ChartSeries
+		FirstItem string `gorm:"column:first_item"`
+		NPK       string `gorm:"column:npk"`
+		Operator  string `gorm:"column:operator"`
+	}
+	identities := map[string]models.OperatorIdentity{}
+	for _, def := range machineProcesses(noMC) {
+		join, joinArgs := chartJoin(def, tanggal, tanggal)
+		args := append([]interface{}{startHour, endHour - 1}, joinArgs...)
+		args = append(append(args, tanggal, noMC), filterArgs...)
+		var raw []hourlyRow
+		if err := database.MySQL.Raw(fmt.Sprintf(query, def.Source(), join, filter), args...).Scan(&raw).Error; err != nil {
+			return nil, fmt.Errorf("proses %s: %w", def.Code, err)
+		}
+		rows := make([]models.ChartSeries, len(raw))
+		for i, r := range raw {
+			operator := "-"
+			if identity := resolveRowOperator(identities, r.NPK, r.Operator); identity.Nama != "" {
+				operator = identity.Nama
+			}
+			rows[i] = r.ChartSeries
+			rows[i].ExtraInfo = fmt.Sprintf("%s (%s)", r.FirstItem, operator)
+		}
+		results = mergeSeries(results, rows)
 	}
+	return results, nil
+}
 
-	c.JSON(http.StatusOK, results)
+// Helper: Join chart untuk alias t = standar (s, o, om) + rework OK per kunci LWP (rw.qty).
+// actual_ok chart = OK + rework OK (OK efektif setelah disposisi REWORK).
+func chartJoin(def process.Definition, from, to string) (string, []interface{}) {
+	join, args := standardJoin(from, to)
+	rework, reworkArgs := reworkJoin(def.Source(), from, to)
+	return join + rework, append(args, reworkArgs...)
+}
+
+// Helper: Sumber proses untuk satu mesin (dari registry mesin, atau semua proses jika belum terdaftar)
+func machineProcesses(noMC string) []process.Definition {
+	var machine models.Machine
+	if err := database.DB.Where("no_mc = ?", noMC).First(&machine).Error; err == nil {
+		if def, ok := process.Get(machine.Proses); ok {
+			return []process.Definition{def}
+		}
+	}
+	return process.All()
+}
+
+// Helper: Gabungkan seri per label (jumlahkan target & aktual), urutan label dipertahankan
+func mergeSeries(base, extra []models.ChartSeries) []models.ChartSeries {
+	index := map[string]int{}
+	for i, s := range base {
+		index[s.Label] = i
+	}
+	for _, s := range extra {
+		i, ok := index[s.Label]
+		if !ok {
+			index[s.Label] = len(base)
+			base = append(base, s)
+			continue
+		}
+		base[i].Target += s.Target
+		base[i].Actual += s.Actual
+		base[i].ActualOK += s.ActualOK
+		base[i].ActualNG += s.ActualNG
+		base[i].ReworkOK += s.ReworkOK
+		if base[i].ItemCode == "-" || base[i].ItemCode == "" {
+			base[i].ItemCode, base[i].ExtraInfo = s.ItemCode, s.ExtraInfo
+		}
+	}
+	sort.SliceStable(base, func(i, j int) bool { return base[i].Label < base[j].Label })
+	return base
 }
@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/process"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper: Ekspresi SQL qty reject dari katalog, digabung jadi satu string "3,0,1,..." (urut katalog).
+// Hanya jenis yang punya kolom sumber data; kolom sudah divalidasi sebagai identifier.
+func defectQtysSelect(alias string, defects []models.DefectType) string {
+	parts := []string{}
+	for _, d := range defects {
+		if d.Column != "" {
+			parts = append(parts, fmt.Sprintf("COALESCE(%s.`%s`, 0)", alias, d.Column))
+		}
+	}
+	if len(parts) == 0 {
+		return "''"
+	}
+	return "CONCAT_WS(',', " + strings.Join(parts, ", ") + ")"
+}
+
+// Helper: Nama kolom sumber reject dari katalog (untuk process.SourceWith)
+func defectColumns(defects []models.DefectType) []string {
+	cols := []string{}
+	for _, d := range defects {
+		if d.Column != "" {
+			cols = append(cols, d.Column)
+		}
+	}
+	return cols
+}
+
+// Helper: Uraikan hasil defectQtysSelect menjadi qty per jenis reject
+func parseRejects(qtys string, defects []models.DefectType) []rejectQty {
+	values := strings.Split(qtys, ",")
+	rejects := []rejectQty{}
+	i := 0
+	for _, d := range defects {
+		if d.Column == "" {
+			continue
+		}
+		qty := 0
+		if i < len(values) {
+			qty, _ = strconv.Atoi(strings.TrimSpace(values[i]))
+		}
+		i++
+		rejects = append(rejects, rejectQty{Kode: d.Code, Nama: d.Label, Severity: d.Severity, Qty: qty})
+	}
+	return rejects
+}
+
+// Helper: Isi Rejects semua record LWP dari kolom defect_qtys
+func fillRejects(records []PressingLWPRecord, defects []models.DefectType) {
+	for i := range records {
+		records[i].Rejects = parseRejects(records[i].DefectQtys, defects)
+	}
+}
+
+// Input qty reject per jenis dari form LWP
+type LWPRejectInput struct {
+	Kode string `json:"kode"`
+	Qty  int    `json:"qty"`
+}
+
+// Helper: Validasi klasifikasi reject LWP terhadap katalog aktif proses (total tidak boleh melebihi NG)
+func buildLWPDefects(proses string, input []LWPRejectInput, ng int) ([]models.LWPDefect, error) {
+	catalog := map[string]bool{}
+	for _, d := range database.DefectTypes(proses, true) {
+		catalog[d.Code] = true
+	}
+
+	qtys := map[string]int{}
+	order := []string{}
+	total := 0
+	for _, r := range input {
+		code := strings.ToUpper(strings.TrimSpace(r.Kode))
+		if r.Qty == 0 {
+			continue
+		}
+		if r.Qty < 0 {
+			return nil, fmt.Errorf("Qty reject %s tidak boleh negatif", code)
+		}
+		if !catalog[code] {
+			return nil, fmt.Errorf("Jenis reject %s tidak ada / tidak aktif di katalog proses %s", code, proses)
+		}
+		if _, seen := qtys[code]; !seen {
+			order = append(order, code)
+		}
+		qtys[code] += r.Qty
+		total += r.Qty
+	}
+	if total > ng {
+		return nil, fmt.Errorf("Total klasifikasi reject (%d) melebihi NG (%d)", total, ng)
+	}
+
+	defects := []models.LWPDefect{}
+	for _, code := range order {
+		defects = append(defects, models.LWPDefect{Proses: proses, Code: code, Qty: qtys[code]})
+	}
+	return defects, nil
+}
+
+// GET: /api/defects?proses=PRS&aktif=true - Katalog jenis reject (dipakai form LWP)
+func GetDefectTypes(c *gin.Context) {
+	query := database.DB.Order("proses asc, urutan asc, id asc")
+	if proses := c.Query("proses"); proses != "" {
+		query = query.Where("proses = ?", strings.ToUpper(proses))
+	}
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", aktif == "true")
+	}
+
+	var defects []models.DefectType
+	if err := query.Find(&defects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil katalog reject"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(defects), "data": defects, "severities": database.DefectSeverities})
+}
+
+// Helper: Validasi severity & kolom sumber data jenis reject
+func validateDefectType(defect models.DefectType) error {
+	valid := false
+	for _, s := range database.DefectSeverities {
+		if defect.Severity == s {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("severity harus salah satu dari %s", strings.Join(database.DefectSeverities, ", "))
+	}
+	if defect.Column == "" {
+		return nil
+	}
+	if !process.IsIdentifier(defect.Column) {
+		return fmt.Errorf("nama kolom tidak valid: %q", defect.Column)
+	}
+	// Pastikan kolom benar-benar ada di sumber data proses (jika MySQL tersambung)
+	def, ok := process.Get(defect.Proses)
+	if ok && database.MySQL != nil {
+		check := fmt.Sprintf("SELECT `%s` FROM `%s` LIMIT 0", defect.Column, def.Table)
+		if err := database.MySQL.Exec(check).Error; err != nil {
+			return fmt.Errorf("kolom %s tidak ditemukan di %s", defect.Column, def.Table)
+		}
+	}
+	return nil
+}
+
+// POST: /admin/defects - Tambah jenis reject ke katalog
+func CreateDefectType(c *gin.Context) {
+	var input struct {
+		Proses   string `json:"proses" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+		Label    string `json:"label" binding:"required"`
+		Column   string `json:"column"`
+		Severity string `json:"severity"`
+		Urutan   int    `json:"urutan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proses, code dan label wajib diisi"})
+		return
+	}
+
+	defect := models.DefectType{
+		Proses:   strings.ToUpper(input.Proses),
+		Code:     strings.ToUpper(strings.TrimSpace(input.Code)),
+		Label:    input.Label,
+		Column:   input.Column,
+		Severity: strings.ToUpper(input.Severity),
+		Urutan:   input.Urutan,
+		Aktif:    true,
+	}
+	if defect.Severity == "" {
+		defect.Severity = "MINOR"
+	}
+	if _, ok := process.Get(defect.Proses); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Proses " + defect.Proses + " tidak terdaftar"})
+		return
+	}
+	if !process.IsIdentifier(defect.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode reject hanya boleh huruf, angka dan underscore"})
+		return
+	}
+	if err := validateDefectType(defect); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if defect.Urutan == 0 {
+		var last models.DefectType
+		database.DB.Where("proses = ?", defect.Proses).Order("urutan desc").Limit(1).Find(&last)
+		defect.Urutan = last.Urutan + 10
+	}
+
+	if err := database.DB.Create(&defect).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Gagal menyimpan jenis reject (kode mungkin sudah ada)"})
+		return
+	}
+
+	recordAuditChange(c, "CREATE_DEFECT_TYPE", "defect_type", defect.ID, nil, defect, defect.Proses+"/"+defect.Code)
+	c.JSON(http.StatusCreated, gin.H{"message": "Jenis reject ditambahkan", "data": defect})
+}
+
+// PUT: /admin/defects/:id - Ubah label, kolom, severity, urutan atau nonaktifkan jenis reject.
+// Jenis reject tidak dihapus agar data LWP lama tetap terbaca.
+func UpdateDefectType(c *gin.Context) {
+	var defect models.DefectType
+	if err := database.DB.First(&defect, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jenis reject tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Label    string  `json:"label"`
+		Column   *string `json:"column"`
+		Severity string  `json:"severity"`
+		Urutan   *int    `json:"urutan"`
+		Aktif    *bool   `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := defect
+	if input.Label != "" {
+		defect.Label = input.Label
+	}
+	if input.Column != nil {
+		defect.Column = *input.Column
+	}
+	if input.Severity != "" {
+		defect.Severity = strings.ToUpper(input.Severity)
+	}
+	if input.Urutan != nil {
+		defect.Urutan = *input.Urutan
+	}
+	if input.Aktif != nil {
+		defect.Aktif = *input.Aktif
+	}
+	if err := validateDefectType(defect); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&defect).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan jenis reject"})
+		return
+	}
+
+	recordAuditChange(c, "UPDATE_DEFECT_TYPE", "defect_type", defect.ID, before, defect, defect.Proses+"/"+defect.Code)
+	c.JSON(http.StatusOK, gin.H{"message": "Jenis reject diupdate", "data": defect})
+}
+
+// Satu batang pareto reject
+type DefectParetoItem struct {
+	Kode      string  `json:"kode"`
+	Jenis     string  `json:"jenis"`
+	Severity  string  `json:"severity"`
+	Sumber    string  `json:"sumber"` // SOURCE = kolom sumber data proses, LWP = input LWP aplikasi
+	Qty       int64   `json:"qty"`
+	Persen    float64 `json:"persen"`
+	Kumulatif float64 `json:"kumulatif"`
+}
+
+// GET: /api/chart/defect-pareto?proses=PRS&from=&to=&no_mc= - Pareto reject sesuai katalog (termasuk jenis nonaktif).
+// Jenis dengan kolom dibaca dari sumber data proses; jenis tanpa kolom dari input LWP (SUBMITTED / APPROVED).
+func GetDefectPareto(c *gin.Context) {
+	proses := strings.ToUpper(c.DefaultQuery("proses", "PRS"))
+	def, ok := process.Get(proses)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Proses " + proses + " tidak terdaftar"})
+		return
+	}
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	noMC := c.Query("no_mc")
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	defects := database.DefectTypes(proses, false)
+	qtys := map[string]int64{}
+
+	// 1. Jenis dengan kolom: SUM per kolom dari tabel sumber proses
+	sums := []string{}
+	columnDefects := []models.DefectType{}
+	for _, d := range defects {
+		if d.Column != "" {
+			sums = append(sums, fmt.Sprintf("COALESCE(SUM(`%s`), 0)", d.Column))
+			columnDefects = append(columnDefects, d)
+		}
+	}
+	if len(sums) > 0 {
+		if !isDBConnected(c) {
+			return
+		}
+		query := fmt.Sprintf("SELECT %s FROM `%s` WHERE `%s` >= ? AND `%s` < ?",
+			strings.Join(sums, ", "), def.Table, def.Columns.Tanggal, def.Columns.Tanggal)
+		args := []interface{}{fromStr, toStr}
+		if noMC != "" {
+			query += fmt.Sprintf(" AND `%s` = ?", def.Columns.NoMC)
+			args = append(args, noMC)
+		}
+
+		values := make([]int64, len(columnDefects))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := database.MySQL.Raw(query, args...).Row().Scan(dest...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data reject: " + err.Error()})
+			return
+		}
+		for i, d := range columnDefects {
+			qtys[d.Code] = values[i]
+		}
+	}
+
+	// 2. Jenis tanpa kolom: qty dari klasifikasi reject LWP aplikasi
+	var captured []struct {
+		Code string
+		Qty  int64
+	}
+	query := database.DB.Table("lwp_defects d").
+		Select("d.code AS code, SUM(d.qty) AS qty").
+		Joins("JOIN lwp_reports r ON r.id = d.lwp_report_id AND r.deleted_at IS NULL").
+		Where("d.deleted_at IS NULL AND d.proses = ? AND r.tanggal >= ? AND r.tanggal < ?", proses, fromStr, toStr).
+		Where("r.status IN ?", []string{"SUBMITTED", "APPROVED"})
+	if noMC != "" {
+		query = query.Where("r.no_mc = ?", noMC)
+	}
+	query.Group("d.code").Scan(&captured)
+	capturedQty := map[string]int64{}
+	for _, row := range captured {
+		capturedQty[row.Code] = row.Qty
+	}
+
+	items := []DefectParetoItem{}
+	var total int64
+	for _, d := range defects {
+		item := DefectParetoItem{Kode: d.Code, Jenis: d.Label, Severity: d.Severity, Sumber: "SOURCE", Qty: qtys[d.Code]}
+		if d.Column == "" {
+			item.Sumber, item.Qty = "LWP", capturedQty[d.Code]
+		}
+		if item.Qty > 0 {
+			items = append(items, item)
+			total += item.Qty
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Qty > items[j].Qty })
+
+	var cumulative int64
+	for i := range items {
+		cumulative += items[i].Qty
+		items[i].Persen = exportRatio(float64(items[i].Qty), float64(total)) * 100
+		items[i].Kumulatif = exportRatio(float64(cumulative), float64(total)) * 100
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proses":   proses,
+		"from":     fromStr,
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"total_ng": total,
+		"data":     items,
+	})
+}
+
+// Helper: Ganti klasifikasi reject satu laporan LWP
+func saveLWPDefects(tx *gorm.DB, reportID uint, defects []models.LWPDefect) error {
+	if err := tx.Unscoped().Where("lwp_report_id = ?", reportID).Delete(&models.LWPDefect{}).Error; err != nil {
+		return err
+	}
+	for i := range defects {
+		defects[i].LWPReportID = reportID
+		if err := tx.Create(&defects[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,562 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Hasil disposisi NG yang dikenal
+var dispositionOutcomes = []string{"REWORK", "SCRAP", "USE_AS_IS"}
+
+// Helper: Total qty NG laporan yang sudah / sedang didisposisi (PENDING + APPROVED)
+func dispositionedQty(tx *gorm.DB, reportID uint) int {
+	var qty int
+	tx.Model(&models.NGDisposition{}).Where("lwp_report_id = ? AND status <> ?", reportID, "REJECTED").
+		Select("COALESCE(SUM(qty), 0)").Scan(&qty)
+	return qty
+}
+
+// Helper: Pastikan NG hasil edit / koreksi tidak lebih kecil dari qty yang sudah didisposisi
+func checkDispositionedNG(c *gin.Context, report models.LWPReport) bool {
+	if used := dispositionedQty(database.DB, report.ID); report.NG < used {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("NG (%d) lebih kecil dari qty yang sudah didisposisi (%d)", report.NG, used)})
+		return false
+	}
+	return true
+}
+
+// Helper: Biaya material per pcs item (0 = belum dikonfigurasi)
+func materialCostOf(itemCode string) float64 {
+	var costs []models.MaterialCost
+	database.DB.Where("item_code = ?", itemCode).Limit(1).Find(&costs)
+	if len(costs) == 0 {
+		return 0
+	}
+	return costs[0].CostPerPcs
+}
+
+// Helper: Item code laporan LWP (kunci biaya material). Laporan lama tanpa item code
+// dicari dari lot genealogy, lalu dari master mold berdasarkan kode part.
+func reportItemCode(report models.LWPReport) string {
+	if report.ItemCode != "" {
+		return report.ItemCode
+	}
+	var lots []models.Lot
+	database.DB.Where("lot_no = ? AND item_code <> ''", report.NoLot).Limit(1).Find(&lots)
+	if len(lots) > 0 {
+		return lots[0].ItemCode
+	}
+	return itemCodeForMold(report.KodePart)
+}
+
+// Kunci pencocokan LWP lokal ke baris ERP: mesin + tanggal + jam mulai HH:MM (sama dengan approvedLotFilter)
+type lwpKey struct {
+	NoMC    string
+	Tanggal string
+	Jam     string
+}
+
+// Helper: Rework OK (disposisi REWORK yang sudah di-approve) per kunci LWP dalam rentang tanggal (inklusif)
+func reworkOKByKey(from, to string) map[lwpKey]int {
+	var reports []models.LWPReport
+	database.DB.Select("no_mc", "tanggal", "jam_mulai", "rework_ok").
+		Where("tanggal >= ? AND tanggal <= ? AND rework_ok > 0", from, to).
+		Find(&reports)
+
+	result := map[lwpKey]int{}
+	for _, r := range reports {
+		result[lwpKey{r.NoMC, r.Tanggal, lwpJamKey(r.JamMulai)}] += r.ReworkOK
+	}
+	return result
+}
+
+// Join rework OK untuk alias t: rw.qty = rework per kunci dibagi jumlah baris ERP dengan kunci yang sama,
+// sehingga SUM(rw.qty) menghitung rework satu kali per kunci. %[1]s = derived table rework, %[2]s = sumber proses.
+const reworkJoinSQL = `
+	LEFT JOIN (
+		SELECT r.noMC, r.tanggal, r.jam, r.qty / COUNT(*) AS qty
+		FROM (%[1]s) r
+		JOIN %[2]s e
+		ON e.noMC = r.noMC COLLATE utf8mb4_unicode_ci
+		AND e.tanggal = r.tanggal
+		AND TIME_FORMAT(e.MULAI, '%%H:%%i') = r.jam
+		GROUP BY r.noMC, r.tanggal, r.jam, r.qty
+	) rw
+	ON t.noMC = rw.noMC COLLATE utf8mb4_unicode_ci
+	AND t.tanggal = rw.tanggal
+	AND TIME_FORMAT(t.MULAI, '%%H:%%i') = rw.jam
+`
+
+// Helper: Fragment join rework OK (alias rw) untuk satu sumber proses + argumennya.
+// Rework dicatat di SQLite sehingga dikirim sebagai derived table seperti standardJoin.
+func reworkJoin(source, from, to string) (string, []interface{}) {
+	table := "SELECT NULL AS noMC, NULL AS tanggal, NULL AS jam, 0 AS qty FROM DUAL WHERE 1 = 0"
+	args := []interface{}{}
+	if rework := reworkOKByKey(from, to); len(rework) > 0 {
+		selects := []string{}
+		for key, qty := range rework {
+			selects = append(selects, "SELECT ? AS noMC, ? AS tanggal, ? AS jam, ? AS qty")
+			args = append(args, key.NoMC, key.Tanggal, key.Jam, qty)
+		}
+		table = strings.Join(selects, " UNION ALL ")
+	}
+	return fmt.Sprintf(reworkJoinSQL, table, source), args
+}
+
+// Helper: Data template notifikasi disposisi
+func dispositionNotifyData(d models.NGDisposition) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           d.ID,
+		"no_lot":       d.NoLot,
+		"no_mc":        d.NoMC,
+		"tanggal":      d.Tanggal,
+		"outcome":      d.Outcome,
+		"qty":          d.Qty,
+		"reason":       d.Reason,
+		"requested_by": d.RequestedBy,
+	}
+}
+
+// GET: /api/dispositions?status=&outcome=&lwp_report_id=&no_mc=&from=&to=
+func GetDispositions(c *gin.Context) {
+	query := database.DB.Order("created_at desc")
+	if v := c.Query("status"); v != "" {
+		query = query.Where("status = ?", strings.ToUpper(v))
+	}
+	if v := c.Query("outcome"); v != "" {
+		query = query.Where("outcome = ?", strings.ToUpper(v))
+	}
+	if v := c.Query("lwp_report_id"); v != "" {
+		query = query.Where("lwp_report_id = ?", v)
+	}
+	if v := c.Query("no_mc"); v != "" {
+		query = query.Where("no_mc = ?", v)
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseDateRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("tanggal >= ? AND tanggal < ?", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	var dispositions []models.NGDisposition
+	query.Limit(200).Find(&dispositions)
+	c.JSON(http.StatusOK, gin.H{"total": len(dispositions), "data": dispositions})
+}
+
+// POST: /api/dispositions - Ajukan disposisi NG dari satu laporan LWP (menunggu approval manager)
+func CreateDisposition(c *gin.Context) {
+	var input struct {
+		LWPReportID uint   `json:"lwp_report_id" binding:"required"`
+		Outcome     string `json:"outcome" binding:"required"`
+		Qty         int    `json:"qty" binding:"required"`
+		DefectCode  string `json:"defect_code"`
+		Reason      string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lwp_report_id, outcome, qty dan reason wajib diisi"})
+		return
+	}
+
+	outcome := strings.ToUpper(input.Outcome)
+	valid := false
+	for _, o := range dispositionOutcomes {
+		if outcome == o {
+			valid = true
+		}
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "outcome harus salah satu dari " + strings.Join(dispositionOutcomes, ", ")})
+		return
+	}
+	if input.Qty <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "qty harus lebih dari 0"})
+		return
+	}
+
+	var report models.LWPReport
+	if err := database.DB.First(&report, input.LWPReportID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Laporan LWP tidak ditemukan"})
+		return
+	}
+	// NG draft / dikembalikan masih bisa berubah, disposisi setelah laporan dikirim
+	if report.Status != "SUBMITTED" && report.Status != "APPROVED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Disposisi hanya untuk laporan LWP yang sudah SUBMITTED / APPROVED"})
+		return
+	}
+
+	defectCode := strings.ToUpper(strings.TrimSpace(input.DefectCode))
+	if defectCode != "" {
+		var count int64
+		database.DB.Model(&models.DefectType{}).Where("proses = ? AND code = ?", report.Proses, defectCode).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis reject " + defectCode + " tidak ada di katalog proses " + report.Proses})
+			return
+		}
+	}
+
+	disposition := models.NGDisposition{
+		LWPReportID: report.ID,
+		NoLot:       report.NoLot,
+		NoMC:        report.NoMC,
+		Proses:      report.Proses,
+		ItemCode:    reportItemCode(report),
+		Tanggal:     report.Tanggal,
+		Outcome:     outcome,
+		Qty:         input.Qty,
+		DefectCode:  defectCode,
+		Reason:      input.Reason,
+		RequestedBy: currentNIK(c),
+		Status:      "PENDING",
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Total disposisi tidak boleh melebihi NG laporan
+		if used := dispositionedQty(tx, report.ID); used+input.Qty > report.NG {
+			return fmt.Errorf("Sisa NG yang belum didisposisi hanya %d pcs", report.NG-used)
+		}
+		return tx.Create(&disposition).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAuditChange(c, "CREATE_NG_DISPOSITION", "ng_disposition", disposition.ID, nil, disposition,
+		fmt.Sprintf("%s %d pcs lot %s", disposition.Outcome, disposition.Qty, disposition.NoLot))
+	notify.NotifyRoles("ng.disposition", []string{"MANAGER"}, dispositionNotifyData(disposition))
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Disposisi NG diajukan", "data": disposition})
+}
+
+// Helper: Ambil disposisi PENDING untuk di-approve / ditolak
+func findPendingDisposition(c *gin.Context) (models.NGDisposition, bool) {
+	var disposition models.NGDisposition
+	if err := database.DB.First(&disposition, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Disposisi tidak ditemukan"})
+		return disposition, false
+	}
+	if disposition.Status != "PENDING" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Disposisi sudah " + disposition.Status})
+		return disposition, false
+	}
+	if disposition.RequestedBy == currentNIK(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Disposisi tidak boleh di-approve oleh pengaju sendiri"})
+		return disposition, false
+	}
+	return disposition, true
+}
+
+// Disposisi sudah diproses request lain di antara pengecekan & penyimpanan
+var errDispositionNotPending = errors.New("Disposisi sudah diproses oleh user lain")
+
+// Helper: Kunci disposisi PENDING secara atomik (UPDATE bersyarat status) di dalam transaksi
+func claimDisposition(tx *gorm.DB, d models.NGDisposition) error {
+	res := tx.Model(&models.NGDisposition{}).Where("id = ? AND status = ?", d.ID, "PENDING").
+		Updates(map[string]interface{}{
+			"status":           d.Status,
+			"approved_by":      d.ApprovedBy,
+			"approved_at":      d.ApprovedAt,
+			"approval_comment": d.ApprovalComment,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errDispositionNotPending
+	}
+	return nil
+}
+
+// POST: /api/dispositions/:id/approve - Approve disposisi.
+// REWORK: qty masuk ke OK laporan LWP & dicatat sebagai lot rework turunan lot asal.
+// SCRAP: biaya material saat ini dicatat sebagai biaya scrap.
+func ApproveDisposition(c *gin.Context) {
+	disposition, ok := findPendingDisposition(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&input)
+
+	before := disposition
+	now := time.Now()
+	disposition.Status = "APPROVED"
+	disposition.ApprovedBy = currentNIK(c)
+	disposition.ApprovedAt = &now
+	disposition.ApprovalComment = input.Comment
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimDisposition(tx, disposition); err != nil {
+			return err
+		}
+		switch disposition.Outcome {
+		case "REWORK":
+			if err := tx.Model(&models.LWPReport{}).Where("id = ?", disposition.LWPReportID).
+				Update("rework_ok", gorm.Expr("rework_ok + ?", disposition.Qty)).Error; err != nil {
+				return err
+			}
+			// Lot rework hanya dibuat jika lot asal tercatat di genealogy
+			var parents int64
+			tx.Model(&models.Lot{}).Where("lot_no = ?", disposition.NoLot).Count(&parents)
+			if parents > 0 {
+				lot := models.Lot{
+					LotNo:       fmt.Sprintf("%s-RW%d", disposition.NoLot, disposition.ID),
+					Proses:      "RWK",
+					ItemCode:    disposition.ItemCode,
+					NoMC:        disposition.NoMC,
+					OperatorNIK: disposition.RequestedBy,
+					Qty:         disposition.Qty,
+					FinishedAt:  &now,
+				}
+				if err := registerLot(tx, &lot, []LotParentInput{{LotNo: disposition.NoLot, Qty: disposition.Qty}}); err != nil {
+					return err
+				}
+				disposition.ReworkLotNo = lot.LotNo
+			}
+		case "SCRAP":
+			disposition.UnitCost = materialCostOf(disposition.ItemCode)
+			disposition.ScrapCost = disposition.UnitCost * float64(disposition.Qty)
+		}
+		return tx.Save(&disposition).Error
+	})
+	if errors.Is(err, errDispositionNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal approve disposisi: " + err.Error()})
+		return
+	}
+
+	recordAuditChange(c, "APPROVE_NG_DISPOSITION", "ng_disposition", disposition.ID, before, disposition, input.Comment)
+	c.JSON(http.StatusOK, gin.H{"message": "Disposisi di-approve", "data": disposition})
+}
+
+// POST: /api/dispositions/:id/reject - Tolak disposisi (qty kembali bisa didisposisi ulang)
+func RejectDisposition(c *gin.Context) {
+	disposition, ok := findPendingDisposition(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Komentar penolakan wajib diisi"})
+		return
+	}
+
+	before := disposition
+	now := time.Now()
+	disposition.Status = "REJECTED"
+	disposition.ApprovedBy = currentNIK(c)
+	disposition.ApprovedAt = &now
+	disposition.ApprovalComment = input.Comment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return claimDisposition(tx, disposition)
+	})
+	if errors.Is(err, errDispositionNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menolak disposisi: " + err.Error()})
+		return
+	}
+
+	recordAuditChange(c, "REJECT_NG_DISPOSITION", "ng_disposition", disposition.ID, before, disposition, input.Comment)
+	c.JSON(http.StatusOK, gin.H{"message": "Disposisi ditolak", "data": disposition})
+}
+
+// Rekap disposisi per item
+type DispositionItemSummary struct {
+	ItemCode  string  `json:"item_code"`
+	Rework    int     `json:"rework"`
+	Scrap     int     `json:"scrap"`
+	UseAsIs   int     `json:"use_as_is"`
+	UnitCost  float64 `json:"unit_cost"` // 0 = biaya material belum dikonfigurasi
+	ScrapCost float64 `json:"scrap_cost"`
+}
+
+// Rekap disposisi NG dalam rentang tanggal (dipakai endpoint summary & laporan harian)
+type DispositionSummary struct {
+	OK        int                      `json:"ok"` // Total hasil OK laporan LWP (SUBMITTED / APPROVED)
+	ReworkOK  int                      `json:"rework_ok"`
+	OKEfektif int                      `json:"ok_efektif"` // OK + rework OK
+	NG        int                      `json:"ng"`         // Total NG laporan LWP (SUBMITTED / APPROVED)
+	Rework    int                      `json:"rework"`
+	Scrap     int                      `json:"scrap"`
+	UseAsIs   int                      `json:"use_as_is"`
+	Pending   int                      `json:"pending"`
+	Open      int                      `json:"open"` // NG yang belum didisposisi
+	ScrapCost float64                  `json:"scrap_cost"`
+	Uncosted  int                      `json:"uncosted_scrap"` // Qty scrap tanpa biaya material
+	Items     []DispositionItemSummary `json:"items"`
+}
+
+// Helper: Hitung rekap disposisi (from inklusif, to eksklusif, format YYYY-MM-DD).
+// Biaya scrap memakai biaya saat approval; jika saat itu belum dikonfigurasi, pakai biaya saat ini.
+func dispositionSummary(from, to string) DispositionSummary {
+	summary := DispositionSummary{Items: []DispositionItemSummary{}}
+
+	var totals struct {
+		OK       int
+		ReworkOK int
+		NG       int
+	}
+	database.DB.Model(&models.LWPReport{}).
+		Where("tanggal >= ? AND tanggal < ? AND status IN ?", from, to, []string{"SUBMITTED", "APPROVED"}).
+		Select("COALESCE(SUM(hasil_ok), 0) AS ok, COALESCE(SUM(rework_ok), 0) AS rework_ok, COALESCE(SUM(ng), 0) AS ng").
+		Scan(&totals)
+	summary.OK, summary.ReworkOK, summary.NG = totals.OK, totals.ReworkOK, totals.NG
+	summary.OKEfektif = summary.OK + summary.ReworkOK
+
+	var dispositions []models.NGDisposition
+	database.DB.Where("tanggal >= ? AND tanggal < ? AND status <> ?", from, to, "REJECTED").Find(&dispositions)
+
+	items := map[string]*DispositionItemSummary{}
+	costs := map[string]float64{}
+	dispositioned := 0
+	for _, d := range dispositions {
+		dispositioned += d.Qty
+		if d.Status == "PENDING" {
+			summary.Pending += d.Qty
+			continue
+		}
+		item, ok := items[d.ItemCode]
+		if !ok {
+			if _, cached := costs[d.ItemCode]; !cached {
+				costs[d.ItemCode] = materialCostOf(d.ItemCode)
+			}
+			item = &DispositionItemSummary{ItemCode: d.ItemCode, UnitCost: costs[d.ItemCode]}
+			items[d.ItemCode] = item
+		}
+		switch d.Outcome {
+		case "REWORK":
+			item.Rework += d.Qty
+			summary.Rework += d.Qty
+		case "USE_AS_IS":
+			item.UseAsIs += d.Qty
+			summary.UseAsIs += d.Qty
+		case "SCRAP":
+			item.Scrap += d.Qty
+			summary.Scrap += d.Qty
+			cost := d.ScrapCost
+			if cost == 0 {
+				cost = costs[d.ItemCode] * float64(d.Qty)
+			}
+			if cost == 0 {
+				summary.Uncosted += d.Qty
+			}
+			item.ScrapCost += cost
+			summary.ScrapCost += cost
+		}
+	}
+	if summary.Open = summary.NG - dispositioned; summary.Open < 0 {
+		summary.Open = 0
+	}
+
+	for _, item := range items {
+		summary.Items = append(summary.Items, *item)
+	}
+	sort.Slice(summary.Items, func(i, j int) bool {
+		if summary.Items[i].ScrapCost != summary.Items[j].ScrapCost {
+			return summary.Items[i].ScrapCost > summary.Items[j].ScrapCost
+		}
+		return summary.Items[i].Scrap > summary.Items[j].Scrap
+	})
+	return summary
+}
+
+// Helper: Tabel laporan disposisi NG per item (dipakai laporan harian)
+func dispositionTable(summary DispositionSummary) exportTable {
+	table := exportTable{Sheet: "Disposisi NG", Title: "Disposisi NG per Item", Columns: []exportColumn{
+		{Header: "Item", Width: 16}, {Header: "Rework", Width: 10, Sum: true}, {Header: "Scrap", Width: 10, Sum: true},
+		{Header: "Use As Is", Width: 10, Sum: true}, {Header: "Biaya/pcs", Width: 12}, {Header: "Biaya Scrap", Width: 14, Sum: true},
+	}}
+	for _, item := range summary.Items {
+		table.Rows = append(table.Rows, []interface{}{item.ItemCode, item.Rework, item.Scrap, item.UseAsIs, item.UnitCost, item.ScrapCost})
+	}
+	return table
+}
+
+// GET: /api/dispositions/summary?from=&to= - Rekap rework / scrap / use-as-is & biaya scrap
+func GetDispositionSummary(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	summary := dispositionSummary(from.Format("2006-01-02"), to.Format("2006-01-02"))
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"data": summary,
+	})
+}
+
+// GET: /api/dispositions/material-costs - Daftar biaya material per item
+func GetMaterialCosts(c *gin.Context) {
+	var costs []models.MaterialCost
+	database.DB.Order("item_code asc").Find(&costs)
+	c.JSON(http.StatusOK, gin.H{"total": len(costs), "data": costs})
+}
+
+// PUT: /api/dispositions/material-costs/:item_code - Set biaya material per pcs (0 = hapus konfigurasi)
+func SaveMaterialCost(c *gin.Context) {
+	var input struct {
+		CostPerPcs float64 `json:"cost_per_pcs"`
+		Currency   string  `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.CostPerPcs < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cost_per_pcs tidak boleh negatif"})
+		return
+	}
+
+	itemCode := c.Param("item_code")
+	var before interface{}
+	var cost models.MaterialCost
+	err := database.DB.Where("item_code = ?", itemCode).First(&cost).Error
+	if err == nil {
+		before = cost
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil biaya material"})
+		return
+	}
+
+	cost.ItemCode = itemCode
+	cost.CostPerPcs = input.CostPerPcs
+	if input.Currency != "" {
+		cost.Currency = strings.ToUpper(input.Currency)
+	}
+	if cost.Currency == "" {
+		cost.Currency = "IDR"
+	}
+	cost.UpdatedBy = currentNIK(c)
+	if err := database.DB.Save(&cost).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan biaya material"})
+		return
+	}
+
+	recordAuditChange(c, "SAVE_MATERIAL_COST", "material_cost", cost.ID, before, cost, itemCode)
+	c.JSON(http.StatusOK, gin.H{"message": "Biaya material disimpan", "data": cost})
+}
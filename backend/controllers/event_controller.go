@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/realtime"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ambang reject spike: NG rate di atas 10% dengan minimal 20 pcs
+const rejectSpikeRate = 0.10
+const rejectSpikeMinQty = 20
+
+// Helper: Kirim event ke dashboard. Proses diambil dari registry mesin.
+func publishEvent(eventType, noMC string, data interface{}, roles ...string) {
+	proses := ""
+	if noMC != "" {
+		var machine models.Machine
+		if err := database.DB.Where("no_mc = ?", noMC).First(&machine).Error; err == nil {
+			proses = machine.Proses
+		}
+	}
+
+	realtime.Default.Publish(realtime.Event{
+		Type:   eventType,
+		Proses: proses,
+		NoMC:   noMC,
+		Roles:  roles,
+		Data:   data,
+	})
+}
+
+// Helper: Kirim event reject spike jika NG rate satu input melewati ambang
+func publishRejectSpike(noMC, lotNo string, ok, ng int) {
+	total := ok + ng
+	if total < rejectSpikeMinQty || float64(ng)/float64(total) <= rejectSpikeRate {
+		return
+	}
+	publishEvent(realtime.EventRejectSpike, noMC, gin.H{
+		"no_mc":   noMC,
+		"lot_no":  lotNo,
+		"ok":      ok,
+		"ng":      ng,
+		"ng_rate": float64(ng) / float64(total) * 100,
+	}, "ADMIN", "MANAGER", "LEADER")
+}
+
+// GET: /api/events/stream?proses=PRS&types=machine.state,lwp.created&token=JWT
+// Server-Sent Events untuk dashboard (leader, pressing, report-chart)
+func StreamEvents(c *gin.Context) {
+	filter := realtime.Filter{
+		Role:   currentRole(c),
+		Proses: c.Query("proses"),
+		Types:  map[string]bool{},
+	}
+	if types := c.Query("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter.Types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	sub := realtime.Default.Subscribe(filter)
+	defer realtime.Default.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Ping berkala supaya koneksi tidak diputus proxy / VPN
+	ping := time.NewTicker(25 * time.Second)
+	defer ping.Stop()
+
+	c.SSEvent("ready", gin.H{"role": filter.Role, "proses": filter.Proses})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			return true
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// GET: /api/events/stats - Jumlah koneksi realtime aktif
+func GetEventStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"subscribers": realtime.Default.Count()})
+}
@@ -0,0 +1,564 @@
+package controllers
+
+import (
+	"bytes"
+	"factory-api/database"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Batas rentang tanggal satu kali export (query chart dijalankan per hari)
+const exportMaxDays = 62
+
+// Kolom tabel export. Kolom rasio dihitung Num/Den (di XLSX sebagai formula).
+type exportColumn struct {
+	Header  string
+	Width   float64 // Lebar relatif (karakter)
+	Sum     bool    // Dijumlah di baris total
+	IsRatio bool
+	Num     int // Index kolom pembilang (untuk rasio)
+	Den     int // Index kolom penyebut
+}
+
+// Satu tabel = satu sheet XLSX / satu bagian PDF
+type exportTable struct {
+	Sheet   string
+	Title   string
+	Columns []exportColumn
+	Rows    [][]interface{}
+}
+
+// Informasi header di setiap export
+type exportMeta struct {
+	Company     string
+	Title       string
+	From        time.Time
+	To          time.Time // Inklusif
+	GeneratedBy string
+	GeneratedAt time.Time
+}
+
+// Helper: Nama perusahaan di header export (env COMPANY_NAME)
+func companyName() string {
+	if v := os.Getenv("COMPANY_NAME"); v != "" {
+		return v
+	}
+	return "BESQ Factory"
+}
+
+// Helper: Nilai numerik sel (untuk total & rasio)
+func exportNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// Helper: Rasio aman (0 jika penyebut 0)
+func exportRatio(num, den float64) float64 {
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// Helper: Isi kolom rasio & hitung baris total
+func (t *exportTable) finalize() []interface{} {
+	total := make([]interface{}, len(t.Columns))
+	sums := make([]float64, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, col := range t.Columns {
+			if col.IsRatio {
+				row[i] = exportRatio(exportNumber(row[col.Num]), exportNumber(row[col.Den]))
+			} else if col.Sum {
+				sums[i] += exportNumber(row[i])
+			}
+		}
+	}
+	total[0] = "TOTAL"
+	for i, col := range t.Columns {
+		if col.Sum {
+			total[i] = sums[i]
+		} else if col.IsRatio {
+			total[i] = exportRatio(sums[col.Num], sums[col.Den])
+		}
+	}
+	return total
+}
+
+// Helper: Nama sheet Excel maksimal 31 karakter tanpa karakter terlarang
+func sheetName(name string) string {
+	name = strings.NewReplacer("/", "-", "\\", "-", "?", "", "*", "", "[", "(", "]", ")", ":", "-").Replace(name)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	return name
+}
+
+// Helper: Render tabel ke XLSX (satu sheet per tabel, kolom rasio & total pakai formula)
+func renderExportXLSX(meta exportMeta, tables []exportTable) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	title, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	header, _ := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"D9E1F2"}, Pattern: 1},
+		Border: []excelize.Border{{Type: "bottom", Color: "000000", Style: 1}},
+	})
+	percent, _ := f.NewStyle(&excelize.Style{NumFmt: 10})
+	percentBold, _ := f.NewStyle(&excelize.Style{NumFmt: 10, Font: &excelize.Font{Bold: true}})
+
+	const headerRow = 6
+	for ti, t := range tables {
+		sheet := sheetName(t.Sheet)
+		if ti == 0 {
+			f.SetSheetName("Sheet1", sheet)
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+		total := t.finalize()
+
+		f.SetCellValue(sheet, "A1", meta.Company)
+		f.SetCellStyle(sheet, "A1", "A1", title)
+		f.SetCellValue(sheet, "A2", meta.Title+" - "+t.Title)
+		f.SetCellStyle(sheet, "A2", "A2", bold)
+		f.SetCellValue(sheet, "A3", fmt.Sprintf("Periode: %s s/d %s", meta.From.Format("2006-01-02"), meta.To.Format("2006-01-02")))
+		f.SetCellValue(sheet, "A4", fmt.Sprintf("Dibuat oleh: %s pada %s", meta.GeneratedBy, meta.GeneratedAt.Format("2006-01-02 15:04:05")))
+
+		for ci, col := range t.Columns {
+			cell, _ := excelize.CoordinatesToCellName(ci+1, headerRow)
+			f.SetCellValue(sheet, cell, col.Header)
+			colName, _ := excelize.ColumnNumberToName(ci + 1)
+			f.SetColWidth(sheet, colName, colName, col.Width+2)
+		}
+		first, _ := excelize.CoordinatesToCellName(1, headerRow)
+		last, _ := excelize.CoordinatesToCellName(len(t.Columns), headerRow)
+		f.SetCellStyle(sheet, first, last, header)
+
+		firstData, lastData := headerRow+1, headerRow+len(t.Rows)
+		for ri, row := range t.Rows {
+			r := firstData + ri
+			for ci, col := range t.Columns {
+				cell, _ := excelize.CoordinatesToCellName(ci+1, r)
+				if col.IsRatio {
+					num, _ := excelize.CoordinatesToCellName(col.Num+1, r)
+					den, _ := excelize.CoordinatesToCellName(col.Den+1, r)
+					f.SetCellFormula(sheet, cell, fmt.Sprintf("IF(%s>0,%s/%s,0)", den, num, den))
+					f.SetCellStyle(sheet, cell, cell, percent)
+					continue
+				}
+				f.SetCellValue(sheet, cell, row[ci])
+			}
+		}
+
+		// Baris total: SUM untuk kolom angka, rasio dari hasil SUM
+		totalRow := lastData + 1
+		if len(t.Rows) > 0 {
+			for ci, col := range t.Columns {
+				cell, _ := excelize.CoordinatesToCellName(ci+1, totalRow)
+				colName, _ := excelize.ColumnNumberToName(ci + 1)
+				switch {
+				case ci == 0:
+					f.SetCellValue(sheet, cell, total[0])
+				case col.Sum:
+					f.SetCellFormula(sheet, cell, fmt.Sprintf("SUM(%s%d:%s%d)", colName, firstData, colName, lastData))
+				case col.IsRatio:
+					num, _ := excelize.CoordinatesToCellName(col.Num+1, totalRow)
+					den, _ := excelize.CoordinatesToCellName(col.Den+1, totalRow)
+					f.SetCellFormula(sheet, cell, fmt.Sprintf("IF(%s>0,%s/%s,0)", den, num, den))
+					f.SetCellStyle(sheet, cell, cell, percentBold)
+					continue
+				}
+				f.SetCellStyle(sheet, cell, cell, bold)
+			}
+		}
+		f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: headerRow, TopLeftCell: first, ActivePane: "bottomLeft"})
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Helper: Format sel untuk PDF
+func pdfCell(v interface{}, col exportColumn) string {
+	if col.IsRatio {
+		return fmt.Sprintf("%.1f%%", exportNumber(v)*100)
+	}
+	switch n := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		if n == float64(int64(n)) {
+			return fmt.Sprintf("%d", int64(n))
+		}
+		return fmt.Sprintf("%.2f", n)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Helper: Render tabel ke PDF A4 landscape dengan header & nomor halaman
+func renderExportPDF(meta exportMeta, tables []exportTable) ([]byte, error) {
+	const lineH = 6.0
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetCreator("BESQ Factory API", true)
+	pdf.SetAuthor(meta.GeneratedBy, true)
+	pdf.SetTitle(meta.Title, true)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetAutoPageBreak(true, 15)
+
+	pageW, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	usable := pageW - left - right
+
+	var current exportTable
+	var widths []float64
+	drawColumns := func() {
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(217, 225, 242)
+		for i, col := range current.Columns {
+			pdf.CellFormat(widths[i], lineH, col.Header, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 8)
+	}
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(usable/2, 6, meta.Company, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(usable/2, 6, fmt.Sprintf("Dibuat: %s oleh %s",
+			meta.GeneratedAt.Format("2006-01-02 15:04"), meta.GeneratedBy), "", 1, "R", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(usable/2, 6, meta.Title+" - "+current.Title, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(usable/2, 6, fmt.Sprintf("Periode: %s s/d %s",
+			meta.From.Format("2006-01-02"), meta.To.Format("2006-01-02")), "", 1, "R", false, 0, "")
+		pdf.Ln(2)
+		if len(widths) > 0 {
+			drawColumns() // Header kolom diulang di setiap halaman
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 6, fmt.Sprintf("Halaman %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	for _, t := range tables {
+		total := t.finalize()
+		current = t
+		totalW := 0.0
+		for _, col := range t.Columns {
+			totalW += col.Width
+		}
+		widths = make([]float64, len(t.Columns))
+		for i, col := range t.Columns {
+			widths[i] = usable * col.Width / totalW
+		}
+		pdf.AddPage()
+
+		rows := t.Rows
+		if len(rows) == 0 {
+			pdf.CellFormat(usable, lineH, "Tidak ada data", "1", 1, "C", false, 0, "")
+			continue
+		}
+		for _, row := range rows {
+			for i, col := range t.Columns {
+				align := "L"
+				if col.Sum || col.IsRatio {
+					align = "R"
+				}
+				pdf.CellFormat(widths[i], lineH, pdfCell(row[i], col), "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.SetFont("Helvetica", "B", 8)
+		for i, col := range t.Columns {
+			align := "L"
+			if col.Sum || col.IsRatio {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], lineH, pdfCell(total[i], col), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Helper: Kirim hasil export sesuai format (xlsx / pdf)
+func writeExport(c *gin.Context, format, filename string, meta exportMeta, tables []exportTable) {
+	var data []byte
+	var err error
+	contentType := "application/pdf"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		data, err = renderExportXLSX(meta, tables)
+	} else {
+		data, err = renderExportPDF(meta, tables)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat file export: " + err.Error()})
+		return
+	}
+
+	recordAudit(c, "EXPORT_REPORT", filename+"."+format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// Helper: Validasi format & rentang tanggal export
+func parseExportRequest(c *gin.Context, title string) (string, exportMeta, bool) {
+	format := c.DefaultQuery("format", "xlsx")
+	if format != "xlsx" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format harus xlsx atau pdf"})
+		return "", exportMeta{}, false
+	}
+
+	// Tanpa from/to: export hari ini saja (bukan 30 hari seperti laporan lain)
+	y, m, d := time.Now().Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	if c.Query("from") != "" || c.Query("to") != "" {
+		var err error
+		if from, to, err = parseDateRange(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return "", exportMeta{}, false
+		}
+	}
+	if to.Sub(from).Hours()/24 > exportMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Rentang export maksimal %d hari", exportMaxDays)})
+		return "", exportMeta{}, false
+	}
+
+	return format, exportMeta{
+		Company:     companyName(),
+		Title:       title,
+		From:        from,
+		To:          to.AddDate(0, 0, -1),
+		GeneratedBy: currentNIK(c),
+		GeneratedAt: time.Now(),
+	}, true
+}
+
+// Kolom standar target vs aktual (index 0..1 = label, 2 target, 3 aktual, 4 NG)
+func chartColumns(labels ...string) []exportColumn {
+	cols := []exportColumn{}
+	for _, l := range labels {
+		cols = append(cols, exportColumn{Header: l, Width: 14})
+	}
+	n := len(cols)
+	return append(cols,
+		exportColumn{Header: "Target", Width: 12, Sum: true},
+		exportColumn{Header: "Aktual", Width: 12, Sum: true},
+		exportColumn{Header: "NG", Width: 10, Sum: true},
+		exportColumn{Header: "Pencapaian", Width: 12, IsRatio: true, Num: n + 1, Den: n},
+		exportColumn{Header: "NG %", Width: 10, IsRatio: true, Num: n + 2, Den: n + 1},
+	)
+}
+
+// GET: /api/chart/export?format=xlsx|pdf&from=&to=&proses=&no_mc=&shift=&approved_only=
+// Sheet: ringkasan per proses, detail per mesin tiap proses, dan per jam jika no_mc diisi
+func ExportChartReport(c *gin.Context) {
+	if !isDBConnected(c) {
+		return
+	}
+	format, meta, ok := parseExportRequest(c, "Laporan Produksi")
+	if !ok {
+		return
+	}
+
+	summary := exportTable{Sheet: "Ringkasan", Title: "Target vs Aktual per Proses", Columns: chartColumns("Tanggal", "Proses")}
+	processTables := map[string]*exportTable{}
+	processOrder := []string{}
+	onlyProses := c.Query("proses")
+
+	var machine *exportTable
+	noMC := c.Query("no_mc")
+	if noMC != "" {
+		machine = &exportTable{Sheet: "Mesin " + noMC, Title: "Per Jam Mesin " + noMC,
+			Columns: chartColumns("Tanggal", "Jam", "Item")}
+	}
+	startHour, endHour := shiftHours(c.Query("shift"))
+
+	for day := meta.From; !day.After(meta.To); day = day.AddDate(0, 0, 1) {
+		tanggal := day.Format("2006-01-02")
+		filter, filterArgs := approvedLotFilter(c, tanggal)
+
+		series, err := managerSeries(tanggal, filter, filterArgs...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data manager: " + err.Error()})
+			return
+		}
+		for _, s := range series {
+			if onlyProses != "" && s.Label != onlyProses {
+				continue
+			}
+			summary.Rows = append(summary.Rows, []interface{}{tanggal, s.Label, s.Target, s.Actual, s.ActualNG, nil, nil})
+
+			pt, exists := processTables[s.Label]
+			if !exists {
+				pt = &exportTable{Sheet: "Proses " + s.Label, Title: "Per Mesin Proses " + s.Label,
+					Columns: chartColumns("Tanggal", "Mesin")}
+				processTables[s.Label] = pt
+				processOrder = append(processOrder, s.Label)
+			}
+			perMachine, err := processSeries(tanggal, s.Label, filter, filterArgs...)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data proses: " + err.Error()})
+				return
+			}
+			for _, m := range perMachine {
+				pt.Rows = append(pt.Rows, []interface{}{tanggal, m.Label, m.Target, m.Actual, m.ActualNG, nil, nil})
+			}
+		}
+
+		if machine != nil {
+			hourly, err := machineHourlySeries(tanggal, noMC, startHour, endHour, filter, filterArgs...)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil detail mesin: " + err.Error()})
+				return
+			}
+			for _, h := range hourly {
+				machine.Rows = append(machine.Rows, []interface{}{tanggal, h.Label, h.ItemCode, h.Target, h.Actual, h.ActualNG, nil, nil})
+			}
+		}
+	}
+
+	tables := []exportTable{summary}
+	sort.Strings(processOrder)
+	for _, p := range processOrder {
+		tables = append(tables, *processTables[p])
+	}
+	if machine != nil {
+		tables = append(tables, *machine)
+	}
+
+	writeExport(c, format, fmt.Sprintf("laporan-produksi-%s-%s", meta.From.Format("20060102"), meta.To.Format("20060102")), meta, tables)
+}
+
+// GET: /api/pressing/lwp-data/export?format=xlsx|pdf&nik=&from=&to=&proses= (default PRS)
+// Sheet: detail LWP, rekap per mesin & shift, dan klasifikasi reject
+func ExportPressingLWP(c *gin.Context) {
+	if !isDBConnected(c) {
+		return
+	}
+	if c.Query("nik") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter 'nik' wajib diisi"})
+		return
+	}
+	operator, ok := resolveOperatorParam(c, c.Query("nik"))
+	if !ok {
+		return
+	}
+	def, ok := lwpProcessParam(c)
+	if !ok {
+		return
+	}
+	format, meta, ok := parseExportRequest(c, fmt.Sprintf("LWP %s %s (%s)", def.Name, operator.Nama, operator.NIK))
+	if !ok {
+		return
+	}
+
+	detail := exportTable{Sheet: "LWP", Title: "Detail LWP", Columns: []exportColumn{
+		{Header: "Tanggal", Width: 11}, {Header: "Mesin", Width: 8}, {Header: "Shift", Width: 6},
+		{Header: "Part", Width: 22}, {Header: "Kode Part", Width: 14}, {Header: "Lot", Width: 18},
+		{Header: "Mulai", Width: 7}, {Header: "Selesai", Width: 7},
+		{Header: "OK", Width: 8, Sum: true}, {Header: "NG", Width: 8, Sum: true},
+		{Header: "Total", Width: 8, Sum: true}, {Header: "Jam", Width: 7, Sum: true},
+		{Header: "NG %", Width: 8, IsRatio: true, Num: 9, Den: 10},
+	}}
+	perMachine := exportTable{Sheet: "Per Mesin", Title: "Rekap per Mesin & Shift", Columns: []exportColumn{
+		{Header: "Tanggal", Width: 11}, {Header: "Mesin", Width: 8}, {Header: "Shift", Width: 6},
+		{Header: "Part", Width: 22}, {Header: "Jumlah Lot", Width: 10, Sum: true},
+		{Header: "OK", Width: 8, Sum: true}, {Header: "NG", Width: 8, Sum: true}, {Header: "Total", Width: 8, Sum: true},
+		{Header: "NG %", Width: 8, IsRatio: true, Num: 6, Den: 7},
+	}}
+	rejects := exportTable{Sheet: "Reject", Title: "Klasifikasi Reject", Columns: []exportColumn{
+		{Header: "Jenis Reject", Width: 24}, {Header: "Qty", Width: 10, Sum: true},
+		{Header: "Total NG", Width: 10}, {Header: "Porsi", Width: 10, IsRatio: true, Num: 1, Den: 2},
+	}}
+
+	rejectTotals := map[string]int{}
+	rejectOrder := []string{}
+	totalNG := 0
+
+	defects := database.DefectTypes(def.Code, false)
+	query := lwpRecordSQL(def, defects)
+
+	for day := meta.From; !day.After(meta.To); day = day.AddDate(0, 0, 1) {
+		var records []PressingLWPRecord
+		if err := database.MySQL.Raw(query, operator.NPKs, day.Format("2006-01-02")).Scan(&records).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data LWP: " + err.Error()})
+			return
+		}
+		fillRejects(records, defects)
+		fillReworkOK(records)
+		// Urut jam mulai (query mengurutkan terbaru dulu)
+		sort.SliceStable(records, func(i, j int) bool { return records[i].JamMulai < records[j].JamMulai })
+
+		for _, r := range records {
+			detail.Rows = append(detail.Rows, []interface{}{r.Tanggal, r.NoMC, r.Shift, r.ItemName, r.MoldCode,
+				r.LotNo, r.JamMulai, r.JamSelesai, r.OK, r.NG, r.Total, r.Jam, nil})
+			totalNG += r.NG
+			for _, rq := range r.rejectQtys() {
+				if _, seen := rejectTotals[rq.Nama]; !seen {
+					rejectOrder = append(rejectOrder, rq.Nama)
+				}
+				rejectTotals[rq.Nama] += rq.Qty
+			}
+		}
+
+		groups := groupPressingLWP(records)
+		sort.Slice(groups, func(i, j int) bool {
+			if groups[i].NoMesin != groups[j].NoMesin {
+				return groups[i].NoMesin < groups[j].NoMesin
+			}
+			return groups[i].Shift < groups[j].Shift
+		})
+		for _, g := range groups {
+			ok, ng, total := 0, 0, 0
+			for _, d := range g.Details {
+				ok += d["hasilOk"].(int)
+				ng += d["ng"].(int)
+				total += d["total"].(int)
+			}
+			perMachine.Rows = append(perMachine.Rows, []interface{}{g.Tanggal, g.NoMesin, g.Shift, g.PartName,
+				len(g.Details), ok, ng, total, nil})
+		}
+	}
+
+	for _, name := range rejectOrder {
+		if rejectTotals[name] > 0 {
+			rejects.Rows = append(rejects.Rows, []interface{}{name, rejectTotals[name], totalNG, nil})
+		}
+	}
+	sort.SliceStable(rejects.Rows, func(i, j int) bool {
+		return rejects.Rows[i][1].(int) > rejects.Rows[j][1].(int)
+	})
+
+	writeExport(c, format, fmt.Sprintf("lwp-%s-%s-%s", operator.NIK, meta.From.Format("20060102"), meta.To.Format("20060102")),
+		meta, []exportTable{detail, perMachine, rejects})
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+)
+
+// Prefix payload label, dinaikkan jika format isi label berubah
+const labelPayloadPrefix = "BQ1"
+
+// Isi label: dibaca ulang oleh layar scan pressing
+type LabelPayload struct {
+	LotNo       string `json:"lot_no"`
+	ItemCode    string `json:"item_code"`
+	Qty         int    `json:"qty"`
+	OrderNumber string `json:"order_number"`
+}
+
+// Encode -> "BQ1|LOT|ITEM|QTY|WO" (pendek supaya muat di Code128)
+func (p LabelPayload) Encode() string {
+	clean := func(s string) string { return strings.ReplaceAll(s, "|", "/") }
+	return strings.Join([]string{
+		labelPayloadPrefix, clean(p.LotNo), clean(p.ItemCode), strconv.Itoa(p.Qty), clean(p.OrderNumber),
+	}, "|")
+}
+
+// Helper: Baca kembali isi label. Kode tanpa prefix dianggap nomor lot saja.
+func parseLabelPayload(code string) (LabelPayload, error) {
+	parts := strings.Split(strings.TrimSpace(code), "|")
+	if parts[0] != labelPayloadPrefix {
+		return LabelPayload{LotNo: strings.TrimSpace(code)}, nil
+	}
+	if len(parts) != 5 {
+		return LabelPayload{}, errors.New("format label tidak dikenali")
+	}
+	qty, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return LabelPayload{}, errors.New("qty pada label tidak valid")
+	}
+	return LabelPayload{LotNo: parts[1], ItemCode: parts[2], Qty: qty, OrderNumber: parts[4]}, nil
+}
+
+// Helper: Susun payload label lot dari genealogy / reservasi nomor lot
+func lotLabelPayload(lotNo string) (LabelPayload, error) {
+	payload := LabelPayload{LotNo: lotNo}
+	var woID uint
+
+	var lot models.Lot
+	if err := database.DB.Where("lot_no = ?", lotNo).First(&lot).Error; err == nil {
+		payload.ItemCode = lot.ItemCode
+		payload.Qty = lot.Qty
+		woID = lot.WorkOrderID
+	} else {
+		var res models.LotReservation
+		if err := database.DB.Where("lot_no = ?", lotNo).First(&res).Error; err != nil {
+			return payload, fmt.Errorf("lot %s tidak ditemukan", lotNo)
+		}
+		woID = res.WorkOrderID
+	}
+
+	if woID != 0 {
+		var wo models.WorkOrder
+		if err := database.DB.First(&wo, woID).Error; err == nil {
+			payload.OrderNumber = wo.OrderNumber
+		}
+	}
+	return payload, nil
+}
+
+// Helper: Buat barcode (code128 / qr) dengan ukuran siap cetak
+func renderBarcode(kind, content string) (barcode.Barcode, error) {
+	switch kind {
+	case "qr":
+		bc, err := qr.Encode(content, qr.M, qr.Auto)
+		if err != nil {
+			return nil, err
+		}
+		return barcode.Scale(bc, 256, 256)
+	case "code128", "":
+		bc, err := code128.Encode(content)
+		if err != nil {
+			return nil, err
+		}
+		return barcode.Scale(bc, bc.Bounds().Dx()*3, 100)
+	default:
+		return nil, fmt.Errorf("tipe barcode %s tidak didukung (code128 / qr)", kind)
+	}
+}
+
+// Helper: Konversi barcode ke SVG (satu rect per deretan modul hitam)
+func barcodeSVG(bc image.Image) []byte {
+	b := bc.Bounds()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		b.Dx(), b.Dy(), b.Dx(), b.Dy())
+	buf.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; {
+			if r, _, _, _ := bc.At(x, y).RGBA(); r != 0 {
+				x++
+				continue
+			}
+			start := x
+			for x < b.Max.X {
+				if r, _, _, _ := bc.At(x, y).RGBA(); r != 0 {
+					break
+				}
+				x++
+			}
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="1"/>`, start-b.Min.X, y-b.Min.Y, x-start)
+		}
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// Helper: Susun lembar label A4 (2 kolom x 5 baris), tiap label berisi Code128 + QR
+func labelSheetPDF(payloads []LabelPayload, generatedBy string) ([]byte, error) {
+	const (
+		labelW, labelH = 95.0, 52.0
+		marginX        = 10.0
+		marginY        = 12.0
+		perRow, perCol = 2, 5
+	)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetCreator("BESQ Factory API", true)
+	pdf.SetAuthor(generatedBy, true)
+
+	for i, p := range payloads {
+		slot := i % (perRow * perCol)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := marginX + float64(slot%perRow)*labelW
+		y := marginY + float64(slot/perRow)*labelH
+
+		content := p.Encode()
+		bar, err := renderBarcode("code128", content)
+		if err != nil {
+			return nil, err
+		}
+		qrCode, err := renderBarcode("qr", content)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, img := range map[string]image.Image{"bar": bar, "qr": qrCode} {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				return nil, err
+			}
+			pdf.RegisterImageOptionsReader(fmt.Sprintf("%s-%d", name, i), fpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		}
+
+		pdf.Rect(x, y, labelW-2, labelH-2, "D")
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.Text(x+3, y+6, p.LotNo)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.Text(x+3, y+11, "Item: "+p.ItemCode)
+		pdf.Text(x+3, y+15, fmt.Sprintf("Qty : %d", p.Qty))
+		pdf.Text(x+3, y+19, "WO  : "+p.OrderNumber)
+		pdf.ImageOptions(fmt.Sprintf("qr-%d", i), x+labelW-30, y+2, 26, 26, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.ImageOptions(fmt.Sprintf("bar-%d", i), x+3, y+30, labelW-10, 16, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Helper: Kirim label dalam format png / svg / pdf
+func writeLabel(c *gin.Context, payload LabelPayload, filename string) {
+	format := c.DefaultQuery("format", "png")
+
+	if format == "pdf" {
+		copies, _ := strconv.Atoi(c.DefaultQuery("copies", "1"))
+		if copies < 1 || copies > 50 {
+			copies = 1
+		}
+		payloads := make([]LabelPayload, copies)
+		for i := range payloads {
+			payloads[i] = payload
+		}
+		data, err := labelSheetPDF(payloads, currentNIK(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat PDF label: " + err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, filename))
+		c.Data(http.StatusOK, "application/pdf", data)
+		return
+	}
+
+	bc, err := renderBarcode(c.DefaultQuery("type", "code128"), payload.Encode())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gagal membuat barcode: " + err.Error()})
+		return
+	}
+
+	switch format {
+	case "svg":
+		c.Data(http.StatusOK, "image/svg+xml", barcodeSVG(bc))
+	case "png":
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, bc); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat PNG"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format harus png, svg atau pdf"})
+	}
+}
+
+// GET: /api/labels/lot/:lot_no?type=code128|qr&format=png|svg|pdf
+func GetLotLabel(c *gin.Context) {
+	payload, err := lotLabelPayload(c.Param("lot_no"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Label cutting biasa dicetak sebelum lot selesai, jadi item & qty boleh diisi dari query
+	if item := c.Query("item_code"); item != "" {
+		payload.ItemCode = item
+	}
+	if qty, err := strconv.Atoi(c.Query("qty")); err == nil {
+		payload.Qty = qty
+	}
+
+	writeLabel(c, payload, payload.LotNo)
+}
+
+// GET: /api/labels/work-order/:id?type=code128|qr&format=png|svg|pdf&item_code=
+func GetWorkOrderLabel(c *gin.Context) {
+	var wo models.WorkOrder
+	if err := database.DB.First(&wo, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work Order tidak ditemukan"})
+		return
+	}
+
+	// Field ITEM di payload harus kode item (dibaca scanner), bukan nama part
+	itemCode := wo.ItemCode
+	if itemCode == "" {
+		itemCode = c.Query("item_code")
+	}
+	if itemCode == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Work Order belum punya item_code, isi item_code WO atau kirim ?item_code="})
+		return
+	}
+
+	payload := LabelPayload{ItemCode: itemCode, Qty: wo.Quantity, OrderNumber: wo.OrderNumber}
+	writeLabel(c, payload, wo.OrderNumber)
+}
+
+// POST: /api/labels/sheet - Cetak lembar label PDF.
+// Bisa untuk lot yang sudah ada, atau pesan nomor lot baru sekaligus (field "reserve").
+func PrintLabelSheet(c *gin.Context) {
+	var input struct {
+		Lots []struct {
+			LotNo    string `json:"lot_no" binding:"required"`
+			ItemCode string `json:"item_code"`
+			Qty      int    `json:"qty"`
+		} `json:"lots"`
+		Reserve *struct {
+			Proses      string `json:"proses" binding:"required"`
+			NoMC        string `json:"no_mc" binding:"required"`
+			Jumlah      int    `json:"jumlah"`
+			WorkOrderID uint   `json:"work_order_id"`
+			ItemCode    string `json:"item_code"`
+			Qty         int    `json:"qty"`
+		} `json:"reserve"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var payloads []LabelPayload
+	for _, l := range input.Lots {
+		p, err := lotLabelPayload(l.LotNo)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if l.ItemCode != "" {
+			p.ItemCode = l.ItemCode
+		}
+		if l.Qty > 0 {
+			p.Qty = l.Qty
+		}
+		payloads = append(payloads, p)
+	}
+
+	if r := input.Reserve; r != nil {
+		if r.Jumlah > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Maksimal 100 nomor lot per permintaan"})
+			return
+		}
+		reserved, err := database.NextLotNumbers(r.Proses, r.NoMC, r.Jumlah, r.WorkOrderID, currentNIK(c))
+		if errors.Is(err, database.ErrLotSource) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat nomor lot: " + err.Error()})
+			return
+		}
+		for _, res := range reserved {
+			p, _ := lotLabelPayload(res.LotNo)
+			p.ItemCode = r.ItemCode
+			p.Qty = r.Qty
+			payloads = append(payloads, p)
+		}
+		recordAudit(c, "PRINT_LOT_LABEL",
+			fmt.Sprintf("%d lot baru %s MC %s", len(reserved), r.Proses, r.NoMC))
+	}
+
+	if len(payloads) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Isi lots atau reserve"})
+		return
+	}
+
+	data, err := labelSheetPDF(payloads, currentNIK(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat PDF label: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="label-%s.pdf"`, time.Now().Format("20060102-150405")))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// POST: /api/labels/decode - Bantu layar scan membaca isi label
+func DecodeLabel(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code harus diisi"})
+		return
+	}
+
+	payload, err := parseLabelPayload(input.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": payload})
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import "testing"
+
+func TestLabelPayloadRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload LabelPayload
+		want    LabelPayload
+	}{
+		{"lengkap", LabelPayload{"PRS04A-260201-0001", "ITM-01", 120, "WO-2026-001"},
+			LabelPayload{"PRS04A-260201-0001", "ITM-01", 120, "WO-2026-001"}},
+		{"tanpa WO", LabelPayload{"CUT01-260201-0002", "ITM-02", 0, ""},
+			LabelPayload{"CUT01-260201-0002", "ITM-02", 0, ""}},
+		// Pemisah di dalam nilai diganti agar jumlah field tetap
+		{"nilai berisi pemisah", LabelPayload{"LOT|1", "ITM|X", 5, "WO|9"},
+			LabelPayload{"LOT/1", "ITM/X", 5, "WO/9"}},
+	}
+	for _, tt := range tests {
+		got, err := parseLabelPayload(tt.payload.Encode())
+		if err != nil {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: %+v, seharusnya %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelPayload(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    LabelPayload
+		wantErr bool
+	}{
+		// Label lama / manual: seluruh kode dianggap nomor lot
+		{"PRS04A-260201-0001", LabelPayload{LotNo: "PRS04A-260201-0001"}, false},
+		{"  PRS04A-260201-0001\n", LabelPayload{LotNo: "PRS04A-260201-0001"}, false},
+		{labelPayloadPrefix + "|LOT1|ITM|10|WO-1", LabelPayload{"LOT1", "ITM", 10, "WO-1"}, false},
+		{labelPayloadPrefix + "|LOT1|ITM|10", LabelPayload{}, true},
+		{labelPayloadPrefix + "|LOT1|ITM|10|WO|X", LabelPayload{}, true},
+		{labelPayloadPrefix + "|LOT1|ITM|sepuluh|WO-1", LabelPayload{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLabelPayload(tt.code)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLabelPayload(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseLabelPayload(%q) = %+v, seharusnya %+v", tt.code, got, tt.want)
+		}
+	}
+}
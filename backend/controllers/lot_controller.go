@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas kedalaman trace agar tidak loop tanpa henti jika data genealogy salah
+const maxTraceDepth = 10
+
+// Lot induk yang dipakai untuk membuat lot baru
+type LotParentInput struct {
+	LotNo string `json:"lot_no" binding:"required"`
+	Qty   int    `json:"qty"`
+}
+
+type LotInput struct {
+	LotNo         string           `json:"lot_no" binding:"required"`
+	Proses        string           `json:"proses" binding:"required"`
+	ItemCode      string           `json:"item_code"`
+	MaterialBatch string           `json:"material_batch"`
+	NoMC          string           `json:"no_mc"`
+	MoldCode      string           `json:"mold_code"`
+	OperatorNIK   string           `json:"operator_nik"`
+	WorkOrderID   uint             `json:"work_order_id"`
+	Qty           int              `json:"qty"`
+	StartedAt     *time.Time       `json:"started_at"`
+	FinishedAt    *time.Time       `json:"finished_at"`
+	Parents       []LotParentInput `json:"parents"`
+}
+
+// Error lot induk yang belum terdaftar di genealogy (kesalahan input, bukan error server)
+type parentLotError struct {
+	LotNo string
+}
+
+func (e parentLotError) Error() string {
+	return fmt.Sprintf("lot induk %s tidak ditemukan", e.LotNo)
+}
+
+// Node pohon hasil trace (backward = asal-usul, forward = turunan)
+type TraceNode struct {
+	Lot      models.Lot  `json:"lot"`
+	Qty      int         `json:"qty,omitempty"` // Qty yang mengalir lewat relasi ini
+	Children []TraceNode `json:"children"`
+}
+
+// Helper: Simpan lot beserta relasi ke lot induknya (dipakai juga oleh CreateLWP)
+func registerLot(tx *gorm.DB, lot *models.Lot, parents []LotParentInput) error {
+	if err := tx.Create(lot).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan lot %s: %w", lot.LotNo, err)
+	}
+
+	for _, p := range parents {
+		var parent models.Lot
+		if err := tx.Where("lot_no = ?", p.LotNo).First(&parent).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return parentLotError{LotNo: p.LotNo}
+		} else if err != nil {
+			return fmt.Errorf("gagal membaca lot induk %s: %w", p.LotNo, err)
+		}
+
+		// Material batch diwariskan dari lot induk jika tidak diisi
+		if lot.MaterialBatch == "" && parent.MaterialBatch != "" {
+			lot.MaterialBatch = parent.MaterialBatch
+			if err := tx.Model(lot).Update("material_batch", lot.MaterialBatch).Error; err != nil {
+				return fmt.Errorf("gagal menyimpan material batch lot %s: %w", lot.LotNo, err)
+			}
+		}
+
+		link := models.LotLink{
+			ParentLotID: parent.ID,
+			ChildLotID:  lot.ID,
+			ParentLotNo: parent.LotNo,
+			ChildLotNo:  lot.LotNo,
+			Qty:         p.Qty,
+		}
+		if err := tx.Create(&link).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan relasi lot %s: %w", p.LotNo, err)
+		}
+	}
+	return nil
+}
+
+// Helper: Bangun pohon trace secara rekursif
+func buildTrace(lot models.Lot, qty int, forward bool, depth int, visited map[uint]bool) TraceNode {
+	node := TraceNode{Lot: lot, Qty: qty, Children: []TraceNode{}}
+	if depth >= maxTraceDepth || visited[lot.ID] {
+		return node
+	}
+	// visited berbasis jalur: hanya mencegah siklus, lot yang sama boleh muncul di cabang lain
+	visited[lot.ID] = true
+	defer delete(visited, lot.ID)
+
+	var links []models.LotLink
+	if forward {
+		database.DB.Where("parent_lot_id = ?", lot.ID).Find(&links)
+	} else {
+		database.DB.Where("child_lot_id = ?", lot.ID).Find(&links)
+	}
+
+	for _, l := range links {
+		nextID := l.ParentLotID
+		if forward {
+			nextID = l.ChildLotID
+		}
+		var next models.Lot
+		if err := database.DB.First(&next, nextID).Error; err != nil {
+			continue
+		}
+		node.Children = append(node.Children, buildTrace(next, l.Qty, forward, depth+1, visited))
+	}
+	return node
+}
+
+// Helper: Kumpulkan lot ujung (tidak punya turunan lagi) dari pohon forward
+func collectLeaves(node TraceNode, out map[string]models.Lot) {
+	if len(node.Children) == 0 {
+		out[node.Lot.LotNo] = node.Lot
+		return
+	}
+	for _, child := range node.Children {
+		collectLeaves(child, out)
+	}
+}
+
+// POST: /api/lots - Daftarkan lot baru beserta lot induknya
+func CreateLot(c *gin.Context) {
+	var input LotInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	if input.OperatorNIK == "" {
+		input.OperatorNIK = currentNIK(c)
+	}
+
+	lot := models.Lot{
+		LotNo:         input.LotNo,
+		Proses:        input.Proses,
+		ItemCode:      input.ItemCode,
+		MaterialBatch: input.MaterialBatch,
+		NoMC:          input.NoMC,
+		MoldCode:      input.MoldCode,
+		OperatorNIK:   input.OperatorNIK,
+		WorkOrderID:   input.WorkOrderID,
+		Qty:           input.Qty,
+		StartedAt:     input.StartedAt,
+		FinishedAt:    input.FinishedAt,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return registerLot(tx, &lot, input.Parents)
+	})
+	var parentErr parentLotError
+	if errors.As(err, &parentErr) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(c, "CREATE_LOT", lot.LotNo)
+	c.JSON(http.StatusCreated, gin.H{"message": "Lot berhasil didaftarkan", "data": lot})
+}
+
+// GET: /api/lots?material_batch=X&item_code=Y&proses=PRS
+func GetLots(c *gin.Context) {
+	var lots []models.Lot
+
+	query := database.DB.Order("created_at desc").Limit(200)
+	if batch := c.Query("material_batch"); batch != "" {
+		query = query.Where("material_batch = ?", batch)
+	}
+	if item := c.Query("item_code"); item != "" {
+		query = query.Where("item_code = ?", item)
+	}
+	if proses := c.Query("proses"); proses != "" {
+		query = query.Where("proses = ?", proses)
+	}
+
+	query.Find(&lots)
+	c.JSON(http.StatusOK, gin.H{"total": len(lots), "data": lots})
+}
+
+// GET: /api/lots/:lot_no - Detail lot beserta lot induk langsung
+func GetLot(c *gin.Context) {
+	var lot models.Lot
+	if err := database.DB.Preload("Parents").Where("lot_no = ?", c.Param("lot_no")).First(&lot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lot tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lot})
+}
+
+// GET: /api/lots/:lot_no/trace?direction=backward|forward
+// backward = "lot ini berasal dari mana", forward = "lot ini dipakai di mana saja"
+func TraceLot(c *gin.Context) {
+	var lot models.Lot
+	if err := database.DB.Where("lot_no = ?", c.Param("lot_no")).First(&lot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lot tidak ditemukan"})
+		return
+	}
+
+	direction := c.DefaultQuery("direction", "backward")
+	if direction != "backward" && direction != "forward" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction harus backward atau forward"})
+		return
+	}
+
+	tree := buildTrace(lot, lot.Qty, direction == "forward", 0, map[uint]bool{})
+	c.JSON(http.StatusOK, gin.H{"direction": direction, "trace": tree})
+}
+
+// GET: /api/trace/material/:batch - Lot jadi mana saja yang memakai batch material X
+func TraceMaterialBatch(c *gin.Context) {
+	batch := c.Param("batch")
+
+	var sources []models.Lot
+	database.DB.Where("material_batch = ?", batch).Find(&sources)
+	if len(sources) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tidak ada lot dengan batch material " + batch})
+		return
+	}
+
+	finished := make(map[string]models.Lot)
+	for _, src := range sources {
+		collectLeaves(buildTrace(src, src.Qty, true, 0, map[uint]bool{}), finished)
+	}
+
+	result := []models.Lot{}
+	for _, lot := range finished {
+		result = append(result, lot)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"material_batch": batch,
+		"source_lots":    len(sources),
+		"total":          len(result),
+		"finished_lots":  result,
+	})
+}
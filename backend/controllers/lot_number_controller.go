@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST: /api/lot-numbers/reserve - Pesan nomor lot (dipanggil saat label barcode dicetak)
+func ReserveLotNumbers(c *gin.Context) {
+	var input struct {
+		Proses      string `json:"proses" binding:"required"`
+		NoMC        string `json:"no_mc" binding:"required"`
+		Jumlah      int    `json:"jumlah"` // Berapa label yang dicetak, default 1
+		WorkOrderID uint   `json:"work_order_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	if input.Jumlah > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Maksimal 100 nomor lot per permintaan"})
+		return
+	}
+
+	reservations, err := database.NextLotNumbers(input.Proses, input.NoMC, input.Jumlah, input.WorkOrderID, currentNIK(c))
+	if errors.Is(err, database.ErrLotSource) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat nomor lot: " + err.Error()})
+		return
+	}
+
+	recordAudit(c, "RESERVE_LOT_NUMBER",
+		fmt.Sprintf("%d lot %s MC %s", len(reservations), input.Proses, input.NoMC))
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Nomor lot berhasil dipesan", "data": reservations})
+}
+
+// GET: /admin/lot-formats - Daftar format nomor lot per proses
+func GetLotFormats(c *gin.Context) {
+	var formats []models.LotNumberFormat
+	database.DB.Order("proses asc").Find(&formats)
+
+	c.JSON(http.StatusOK, gin.H{
+		"default": database.DefaultLotPattern,
+		"data":    formats,
+	})
+}
+
+// PUT: /admin/lot-formats/:proses - Set format nomor lot untuk satu proses
+func UpdateLotFormat(c *gin.Context) {
+	proses := c.Param("proses")
+
+	var input struct {
+		Pattern string `json:"pattern" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pattern harus diisi"})
+		return
+	}
+
+	if err := database.ValidateLotPattern(input.Pattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var format models.LotNumberFormat
+	database.DB.Where("proses = ?", proses).FirstOrInit(&format)
+	format.Proses = proses
+	format.Pattern = input.Pattern
+
+	if err := database.DB.Save(&format).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan format lot"})
+		return
+	}
+
+	recordAudit(c, "UPDATE_LOT_FORMAT", proses+" = "+input.Pattern)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Format lot diperbarui",
+		"data":    format,
+		"contoh":  database.FormatLotNumber(format.Pattern, proses, "04A", time.Now(), 1),
+	})
+}
@@ -0,0 +1,481 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"factory-api/process"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper: Susun laporan LWP dari input operator. Proses diambil dari registry mesin (default PRS).
+func newLWPReport(input LWPInput, noLot string) models.LWPReport {
+	proses := "PRS"
+	var machine models.Machine
+	if err := database.DB.Where("no_mc = ?", input.NoMesin).First(&machine).Error; err == nil && machine.Proses != "" {
+		proses = machine.Proses
+	}
+
+	tanggal := input.Tanggal
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+
+	report := models.LWPReport{
+		NoMC:       input.NoMesin,
+		Proses:     proses,
+		Tanggal:    tanggal,
+		Shift:      input.Shift,
+		NIK:        input.Nik,
+		PartName:   input.PartName,
+		KodePart:   input.KodePart,
+		ItemCode:   input.ItemCode,
+		JamMulai:   input.JamMulai,
+		JamSelesai: input.JamSelesai,
+		HasilOK:    input.HasilOk,
+		NG:         input.Ng,
+		NoLot:      noLot,
+		Status:     "DRAFT",
+	}
+	if report.ItemCode == "" {
+		report.ItemCode = itemCodeForMold(input.KodePart)
+	}
+	if !input.Draft {
+		now := time.Now()
+		report.Status = "SUBMITTED"
+		report.SubmittedAt = &now
+	}
+	return report
+}
+
+// Helper: Validasi qty laporan LWP (OK & NG tidak negatif) dan klasifikasi reject.
+// Klasifikasi baru divalidasi ke katalog; jika nil, total klasifikasi lama tetap harus <= NG.
+func validateLWPQuantities(report models.LWPReport, input *[]LWPRejectInput) ([]models.LWPDefect, error) {
+	if report.HasilOK < 0 || report.NG < 0 {
+		return nil, fmt.Errorf("Hasil OK dan NG tidak boleh negatif")
+	}
+	if input != nil {
+		return buildLWPDefects(report.Proses, *input, report.NG)
+	}
+	if report.ID == 0 {
+		return nil, nil
+	}
+	var classified int
+	database.DB.Model(&models.LWPDefect{}).Where("lwp_report_id = ?", report.ID).
+		Select("COALESCE(SUM(qty), 0)").Scan(&classified)
+	if classified > report.NG {
+		return nil, fmt.Errorf("NG (%d) lebih kecil dari total klasifikasi reject (%d), kirim klasifikasiReject baru", report.NG, classified)
+	}
+	return nil, nil
+}
+
+// Helper: Data template notifikasi LWP
+func lwpNotifyData(report models.LWPReport) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      report.ID,
+		"no_mc":   report.NoMC,
+		"proses":  report.Proses,
+		"tanggal": report.Tanggal,
+		"shift":   report.Shift,
+		"nik":     report.NIK,
+		"no_lot":  report.NoLot,
+		"ok":      report.HasilOK,
+		"ng":      report.NG,
+		"comment": report.ReviewComment,
+	}
+}
+
+// Helper: Beri tahu leader ada LWP baru yang menunggu approval (leader proses terkait, fallback semua leader)
+func notifyLWPSubmitted(report models.LWPReport) {
+	var niks []string
+	database.DB.Model(&models.LeaderProcess{}).Where("proses = ?", report.Proses).Pluck("nik", &niks)
+	if len(niks) > 0 {
+		notify.NotifyUsers("lwp.submitted", niks, lwpNotifyData(report))
+		return
+	}
+	notify.NotifyRoles("lwp.submitted", []string{"LEADER"}, lwpNotifyData(report))
+}
+
+// Helper: Proses yang menjadi tanggung jawab leader login (nil = tanpa batasan untuk ADMIN / MANAGER)
+func leaderProcesses(c *gin.Context) (processes []string, restricted bool) {
+	if currentRole(c) != "LEADER" {
+		return nil, false
+	}
+	database.DB.Model(&models.LeaderProcess{}).Where("nik = ?", currentNIK(c)).Pluck("proses", &processes)
+	return processes, true
+}
+
+// Helper: Pastikan leader hanya memproses laporan dari prosesnya sendiri
+func canReviewProcess(c *gin.Context, proses string) bool {
+	processes, restricted := leaderProcesses(c)
+	if !restricted {
+		return true
+	}
+	for _, p := range processes {
+		if p == proses {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: Ambil laporan & pastikan yang mengubah adalah pemilik (atau ADMIN)
+func findOwnLWPReport(c *gin.Context) (models.LWPReport, bool) {
+	var report models.LWPReport
+	if err := database.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Laporan LWP tidak ditemukan"})
+		return report, false
+	}
+	if report.NIK != currentNIK(c) && currentRole(c) != "ADMIN" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hanya operator pembuat yang boleh mengubah laporan ini"})
+		return report, false
+	}
+	if report.Status == "APPROVED" {
+		c.JSON(http.StatusLocked, gin.H{"error": "Laporan sudah di-approve dan terkunci", "data": report})
+		return report, false
+	}
+	return report, true
+}
+
+// GET: /api/lwp/reports?status=SUBMITTED&nik=&tanggal= - Daftar laporan LWP (default milik sendiri untuk operator)
+func GetLWPReports(c *gin.Context) {
+	query := database.DB.Order("created_at desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if tanggal := c.Query("tanggal"); tanggal != "" {
+		query = query.Where("tanggal = ?", tanggal)
+	}
+
+	nik := c.Query("nik")
+	switch currentRole(c) {
+	case "ADMIN", "MANAGER", "LEADER":
+	default:
+		nik = currentNIK(c) // Operator hanya melihat laporannya sendiri
+	}
+	if nik != "" {
+		if operator, err := database.ResolveOperator(nik); err == nil {
+			nik = operator.NIK
+		}
+		query = query.Where("nik = ?", nik)
+	}
+
+	var reports []models.LWPReport
+	query.Limit(200).Find(&reports)
+	c.JSON(http.StatusOK, gin.H{"total": len(reports), "data": reports})
+}
+
+// PUT: /api/lwp/:id - Edit laporan DRAFT / RETURNED (APPROVED terkunci)
+func UpdateLWPReport(c *gin.Context) {
+	report, ok := findOwnLWPReport(c)
+	if !ok {
+		return
+	}
+	if report.Status == "SUBMITTED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Laporan sedang menunggu approval, tidak bisa diedit"})
+		return
+	}
+
+	var input struct {
+		Tanggal    string `json:"tanggal"`
+		Shift      string `json:"shift"`
+		PartName   string `json:"partName"`
+		KodePart   string `json:"kodePart"`
+		JamMulai   string `json:"jamMulai"`
+		JamSelesai string `json:"jamSelesai"`
+		HasilOk    *int   `json:"hasilOk"`
+		Ng         *int   `json:"ng"`
+		Reason     string `json:"reason"`
+
+		KlasifikasiReject *[]LWPRejectInput `json:"klasifikasiReject"` // nil = klasifikasi lama tetap
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if input.Reason == "" {
+		input.Reason = "Edit " + report.Status
+	}
+	if err := validateLWPTimes(input.Tanggal, input.JamMulai, input.JamSelesai); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := report
+	if input.Tanggal != "" {
+		report.Tanggal = input.Tanggal
+	}
+	if input.Shift != "" {
+		report.Shift = input.Shift
+	}
+	if input.PartName != "" {
+		report.PartName = input.PartName
+	}
+	if input.KodePart != "" {
+		report.KodePart = input.KodePart
+	}
+	if input.JamMulai != "" {
+		report.JamMulai = input.JamMulai
+	}
+	if input.JamSelesai != "" {
+		report.JamSelesai = input.JamSelesai
+	}
+	if input.HasilOk != nil {
+		report.HasilOK = *input.HasilOk
+	}
+	if input.Ng != nil {
+		report.NG = *input.Ng
+	}
+
+	rejects, err := validateLWPQuantities(report, input.KlasifikasiReject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkDispositionedNG(c, report) {
+		return
+	}
+
+	// Setiap edit dicatat sebagai revisi; cycle & lot ikut disesuaikan agar genealogy tetap konsisten
+	if _, err := saveLWPRevision(before, &report, currentNIK(c), input.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan laporan LWP"})
+		return
+	}
+
+	if rejects != nil {
+		if err := saveLWPDefects(database.DB, report.ID, rejects); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan klasifikasi reject"})
+			return
+		}
+	}
+
+	recordAuditChange(c, "UPDATE_LWP", "lwp", report.ID, before, report, input.Reason)
+	c.JSON(http.StatusOK, gin.H{"message": "Laporan LWP diupdate", "data": report})
+}
+
+// POST: /api/lwp/:id/submit - Kirim draft / laporan yang dikembalikan ke leader
+func SubmitLWPReport(c *gin.Context) {
+	report, ok := findOwnLWPReport(c)
+	if !ok {
+		return
+	}
+	if report.Status == "SUBMITTED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Laporan sudah disubmit"})
+		return
+	}
+
+	// UPDATE bersyarat status yang dibaca: submit ganda / perubahan bersamaan ditolak,
+	// kolom lain (misal rework_ok dari disposisi) tidak ikut ditimpa
+	now := time.Now()
+	res := database.DB.Model(&models.LWPReport{}).Where("id = ? AND status = ?", report.ID, report.Status).
+		Updates(map[string]interface{}{"status": "SUBMITTED", "submitted_at": &now})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal submit laporan LWP"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Laporan sudah diubah oleh user lain, muat ulang"})
+		return
+	}
+	report.Status = "SUBMITTED"
+	report.SubmittedAt = &now
+
+	notifyLWPSubmitted(report)
+	recordAudit(c, "SUBMIT_LWP", fmt.Sprintf("#%d lot %s", report.ID, report.NoLot))
+	c.JSON(http.StatusOK, gin.H{"message": "Laporan dikirim ke leader untuk approval", "data": report})
+}
+
+// GET: /api/lwp/approvals?proses=PRS - Antrian approval leader per proses
+// Leader hanya melihat proses yang di-assign ke dirinya (lihat /admin/leader-processes).
+func GetLWPApprovalQueue(c *gin.Context) {
+	query := database.DB.Where("status = ?", "SUBMITTED").Order("submitted_at asc")
+	proses := c.Query("proses")
+	if processes, restricted := leaderProcesses(c); restricted {
+		if len(processes) == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Leader belum di-assign ke proses mana pun, hubungi admin"})
+			return
+		}
+		if proses != "" && !canReviewProcess(c, proses) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Proses " + proses + " bukan tanggung jawab leader ini"})
+			return
+		}
+		if proses == "" {
+			query = query.Where("proses IN ?", processes)
+		}
+	}
+	if proses != "" {
+		query = query.Where("proses = ?", proses)
+	}
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+
+	var reports []models.LWPReport
+	query.Find(&reports)
+	c.JSON(http.StatusOK, gin.H{"total": len(reports), "data": reports})
+}
+
+// Helper: Proses keputusan leader (approve / return)
+func reviewLWPReport(c *gin.Context, status, comment string) {
+	var report models.LWPReport
+	if err := database.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Laporan LWP tidak ditemukan"})
+		return
+	}
+	if report.Status != "SUBMITTED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Laporan berstatus " + report.Status + ", bukan SUBMITTED"})
+		return
+	}
+	if !canReviewProcess(c, report.Proses) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Proses " + report.Proses + " bukan tanggung jawab leader ini"})
+		return
+	}
+
+	// UPDATE bersyarat status SUBMITTED: dua leader bersamaan -> hanya satu yang berhasil
+	now := time.Now()
+	res := database.DB.Model(&models.LWPReport{}).Where("id = ? AND status = ?", report.ID, "SUBMITTED").
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    currentNIK(c),
+			"reviewed_at":    &now,
+			"review_comment": comment,
+		})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan keputusan review"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Laporan sudah direview oleh leader lain"})
+		return
+	}
+	report.Status = status
+	report.ReviewedBy = currentNIK(c)
+	report.ReviewedAt = &now
+	report.ReviewComment = comment
+
+	code := "lwp.approved"
+	if status == "RETURNED" {
+		code = "lwp.returned"
+	}
+	notify.NotifyUsers(code, []string{report.NIK}, lwpNotifyData(report))
+	recordAudit(c, "REVIEW_LWP",
+		fmt.Sprintf("#%d lot %s -> %s", report.ID, report.NoLot, status))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Laporan " + status, "data": report})
+}
+
+// POST: /api/lwp/:id/approve - Leader menyetujui laporan (terkunci setelahnya)
+func ApproveLWPReport(c *gin.Context) {
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&input)
+	reviewLWPReport(c, "APPROVED", input.Comment)
+}
+
+// POST: /api/lwp/:id/return - Leader mengembalikan laporan dengan komentar
+func ReturnLWPReport(c *gin.Context) {
+	var input struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Komentar wajib diisi saat mengembalikan laporan"})
+		return
+	}
+	reviewLWPReport(c, "RETURNED", input.Comment)
+}
+
+// Helper: Filter chart ?approved_only=true -> hanya baris ERP dari LWP yang sudah di-approve.
+// LWP lokal dan vtrx_lwp_prs tidak berbagi nomor lot (lot lokal dari layanan nomor lot, lotNo ERP diisi sistem ERP),
+// jadi keduanya dicocokkan lewat kunci yang sama-sama ada: mesin + tanggal + jam mulai (HH:MM).
+func approvedLotFilter(c *gin.Context, tanggal string) (string, []interface{}) {
+	if c.Query("approved_only") != "true" {
+		return "", nil
+	}
+
+	var reports []models.LWPReport
+	database.DB.Select("no_mc", "tanggal", "jam_mulai").
+		Where("status = ? AND tanggal = ?", "APPROVED", tanggal).
+		Find(&reports)
+
+	var keys [][]interface{}
+	for _, r := range reports {
+		keys = append(keys, []interface{}{r.NoMC, r.Tanggal, lwpJamKey(r.JamMulai)})
+	}
+	if len(keys) == 0 {
+		return " AND 1 = 0", nil
+	}
+	return " AND (t.noMC, t.tanggal, TIME_FORMAT(t.MULAI, '%H:%i')) IN ?", []interface{}{keys}
+}
+
+// Helper: Jam LWP dalam bentuk HH:MM (input bisa HH:MM atau HH:MM:SS)
+func lwpJamKey(jam string) string {
+	jam = strings.TrimSpace(jam)
+	if len(jam) > 5 {
+		return jam[:5]
+	}
+	return jam
+}
+
+// GET: /admin/leader-processes?nik= - Daftar penugasan leader ke proses
+func GetLeaderProcesses(c *gin.Context) {
+	query := database.DB.Order("nik asc, proses asc")
+	if nik := c.Query("nik"); nik != "" {
+		query = query.Where("nik = ?", nik)
+	}
+	var rows []models.LeaderProcess
+	query.Find(&rows)
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// POST: /admin/leader-processes - Tugaskan leader ke satu proses
+func CreateLeaderProcess(c *gin.Context) {
+	var input struct {
+		NIK    string `json:"nik" binding:"required"`
+		Proses string `json:"proses" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nik dan proses wajib diisi"})
+		return
+	}
+	input.Proses = strings.ToUpper(input.Proses)
+	if _, ok := process.Get(input.Proses); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Proses " + input.Proses + " tidak terdaftar"})
+		return
+	}
+	if _, ok := resolveOperatorParam(c, input.NIK); !ok {
+		return
+	}
+
+	row := models.LeaderProcess{NIK: input.NIK, Proses: input.Proses, CreatedBy: currentNIK(c)}
+	if err := database.DB.Create(&row).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Leader sudah di-assign ke proses ini"})
+		return
+	}
+
+	recordAuditChange(c, "CREATE_LEADER_PROCESS", "leader_process", row.ID, nil, row, row.NIK+" -> "+row.Proses)
+	c.JSON(http.StatusCreated, gin.H{"message": "Leader di-assign ke proses", "data": row})
+}
+
+// DELETE: /admin/leader-processes/:id
+func DeleteLeaderProcess(c *gin.Context) {
+	var row models.LeaderProcess
+	if err := database.DB.First(&row, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penugasan tidak ditemukan"})
+		return
+	}
+	// Hapus permanen agar penugasan yang sama bisa dibuat ulang (unique index)
+	if err := database.DB.Unscoped().Delete(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus penugasan"})
+		return
+	}
+
+	recordAuditChange(c, "DELETE_LEADER_PROCESS", "leader_process", row.ID, row, nil, row.NIK+" -> "+row.Proses)
+	c.JSON(http.StatusOK, gin.H{"message": "Penugasan leader dihapus"})
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/realtime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET: /api/machines?proses=PRS - Daftar mesin dari registry
+func GetMachines(c *gin.Context) {
+	var machines []models.Machine
+
+	query := database.DB.Order("no_mc asc")
+	if proses := c.Query("proses"); proses != "" {
+		query = query.Where("proses = ?", proses)
+	}
+
+	if err := query.Find(&machines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data mesin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": len(machines), "data": machines})
+}
+
+// POST: /admin/machines - Daftarkan mesin baru
+func CreateMachine(c *gin.Context) {
+	var input struct {
+		NoMC   string `json:"no_mc" binding:"required"`
+		Nama   string `json:"nama"`
+		Proses string `json:"proses" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	machine := models.Machine{
+		NoMC:   input.NoMC,
+		Nama:   input.Nama,
+		Proses: input.Proses,
+		Status: "IDLE",
+		Aktif:  true,
+	}
+
+	if err := database.DB.Create(&machine).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan mesin (No MC mungkin sudah ada)"})
+		return
+	}
+
+	recordAudit(c, "CREATE_MACHINE", machine.NoMC)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Mesin berhasil didaftarkan", "data": machine})
+}
+
+// PUT: /admin/machines/:no_mc - Update data mesin (nama, proses, aktif)
+func UpdateMachine(c *gin.Context) {
+	var machine models.Machine
+	if err := database.DB.Where("no_mc = ?", c.Param("no_mc")).First(&machine).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mesin tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Nama   string `json:"nama"`
+		Proses string `json:"proses"`
+		Aktif  *bool  `json:"aktif"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Nama != "" {
+		machine.Nama = input.Nama
+	}
+	if input.Proses != "" {
+		machine.Proses = input.Proses
+	}
+	if input.Aktif != nil {
+		machine.Aktif = *input.Aktif
+	}
+
+	if err := database.DB.Save(&machine).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data mesin"})
+		return
+	}
+	recordAudit(c, "UPDATE_MACHINE", machine.NoMC)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mesin berhasil diupdate", "data": machine})
+}
+
+// Helper: Ubah status mesin di registry (IDLE, RUNNING, DOWN, REPAIR, PM)
+func setMachineStatus(noMC, status string) {
+	database.DB.Model(&models.Machine{}).Where("no_mc = ?", noMC).Update("status", status)
+	publishEvent(realtime.EventMachineState, noMC, gin.H{"no_mc": noMC, "status": status})
+}
+
+// Helper: Status mesin saat siap pakai - RUNNING jika masih ada jadwal berjalan, selain itu IDLE
+func machineReadyStatus(noMC string) string {
+	var running int64
+	database.DB.Model(&models.Schedule{}).Where("no_mc = ? AND status = ?", noMC, "RUNNING").Count(&running)
+	if running > 0 {
+		return "RUNNING"
+	}
+	return "IDLE"
+}
@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Tugas PM dianggap DUE sejak 3 hari / 10% interval sebelum jatuh tempo
+const pmDueLeadDays = 3
+const pmDueLeadRatio = 0.1
+
+// Status tugas PM hasil hitung terhadap counter mesin saat ini
+type PMTaskStatus struct {
+	models.PMTask
+	CurrentValue float64 `json:"current_value"`
+	DueStatus    string  `json:"due_status"` // OK, DUE, OVERDUE, DONE
+}
+
+// Helper: Counter mesin dari LWP -> total jam jalan & total shot (pcs / cavity mold)
+func machineCounters(noMC string) (runHours float64, shots float64) {
+	if database.MySQL == nil {
+		return 0, 0
+	}
+
+	type row struct {
+		MoldCode string
+		Hours    float64
+		Total    float64
+	}
+	var rows []row
+	query := `
+		SELECT
+			moldCode AS mold_code,
+			COALESCE(SUM(TIME_TO_SEC(TIMEDIFF(SELESAI, MULAI))) / 3600.0, 0) AS hours,
+			COALESCE(SUM(Total), 0) AS total
+		FROM vtrx_lwp_prs
+		WHERE noMC = ?
+		GROUP BY moldCode
+	`
+	database.MySQL.Raw(query, noMC).Scan(&rows)
+
+	for _, r := range rows {
+		runHours += r.Hours
+		cavity := 1
+		var mold models.Mold
+		if err := database.DB.Where("mold_code = ?", r.MoldCode).First(&mold).Error; err == nil && mold.Cavity > 0 {
+			cavity = mold.Cavity
+		}
+		shots += r.Total / float64(cavity)
+	}
+	return runHours, shots
+}
+
+// Helper: Counter sesuai tipe trigger plan
+func counterFor(triggerType string, runHours, shots float64) float64 {
+	switch triggerType {
+	case "RUN_HOURS":
+		return runHours
+	case "SHOTS":
+		return shots
+	}
+	return 0
+}
+
+// Helper: Buat tugas OPEN berikutnya untuk plan (jika belum ada)
+func generateNextPMTask(plan models.PMPlan) (*models.PMTask, error) {
+	var open int64
+	database.DB.Model(&models.PMTask{}).Where("plan_id = ? AND status = ?", plan.ID, "OPEN").Count(&open)
+	if open > 0 {
+		return nil, nil
+	}
+
+	task := models.PMTask{
+		PlanID:      plan.ID,
+		NoMC:        plan.NoMC,
+		Nama:        plan.Nama,
+		TriggerType: plan.TriggerType,
+		Critical:    plan.Critical,
+		Status:      "OPEN",
+	}
+
+	if plan.TriggerType == "CALENDAR" {
+		base := plan.CreatedAt
+		if plan.LastDoneAt != nil {
+			base = *plan.LastDoneAt
+		}
+		due := base.AddDate(0, 0, int(plan.IntervalValue))
+		task.DueAt = &due
+	} else {
+		task.DueValue = plan.LastDoneValue + plan.IntervalValue
+	}
+
+	if err := database.DB.Create(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Helper: Hitung status jatuh tempo tugas PM
+func evaluatePMTask(task models.PMTask, runHours, shots float64) PMTaskStatus {
+	st := PMTaskStatus{PMTask: task, DueStatus: "OK"}
+	if task.Status == "DONE" {
+		st.DueStatus = "DONE"
+		return st
+	}
+
+	if task.TriggerType == "CALENDAR" {
+		if task.DueAt == nil {
+			return st
+		}
+		now := time.Now()
+		if now.After(*task.DueAt) {
+			st.DueStatus = "OVERDUE"
+		} else if now.AddDate(0, 0, pmDueLeadDays).After(*task.DueAt) {
+			st.DueStatus = "DUE"
+		}
+		return st
+	}
+
+	st.CurrentValue = counterFor(task.TriggerType, runHours, shots)
+	var plan models.PMPlan
+	database.DB.Unscoped().First(&plan, task.PlanID)
+	if st.CurrentValue >= task.DueValue {
+		st.DueStatus = "OVERDUE"
+	} else if st.CurrentValue >= task.DueValue-plan.IntervalValue*pmDueLeadRatio {
+		st.DueStatus = "DUE"
+	}
+	return st
+}
+
+// Helper: Tugas PM terbuka sebuah mesin beserta statusnya (hanya dari plan yang masih aktif)
+func machinePMStatus(noMC string) []PMTaskStatus {
+	var tasks []models.PMTask
+	database.DB.Joins("JOIN pm_plans ON pm_plans.id = pm_tasks.plan_id AND pm_plans.aktif = ? AND pm_plans.deleted_at IS NULL", true).
+		Where("pm_tasks.no_mc = ? AND pm_tasks.status = ?", noMC, "OPEN").Find(&tasks)
+
+	result := []PMTaskStatus{}
+	if len(tasks) == 0 {
+		return result
+	}
+	runHours, shots := machineCounters(noMC)
+	for _, t := range tasks {
+		result = append(result, evaluatePMTask(t, runHours, shots))
+	}
+	return result
+}
+
+// Helper: Cek PM sebelum mesin dijalankan.
+// blocking = PM kritis yang overdue (mesin tidak boleh start), warnings = PM lain yang due/overdue
+func checkMachinePM(noMC string) (blocking []PMTaskStatus, warnings []PMTaskStatus) {
+	for _, st := range machinePMStatus(noMC) {
+		switch {
+		case st.DueStatus == "OVERDUE" && st.Critical:
+			blocking = append(blocking, st)
+		case st.DueStatus == "OVERDUE" || st.DueStatus == "DUE":
+			warnings = append(warnings, st)
+		}
+	}
+	return blocking, warnings
+}
+
+// Helper: Respon standar jika mesin diblok karena PM kritis overdue. Return true jika diblok.
+func abortIfPMBlocked(c *gin.Context, noMC string) (bool, []PMTaskStatus) {
+	if noMC == "" {
+		return false, nil
+	}
+	blocking, warnings := checkMachinePM(noMC)
+	if len(blocking) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    fmt.Sprintf("Mesin %s punya PM kritis yang overdue, selesaikan PM dulu", noMC),
+			"blocking": blocking,
+			"warnings": warnings,
+		})
+		return true, warnings
+	}
+	return false, warnings
+}
+
+// GET: /api/pm/plans?no_mc=04A
+func GetPMPlans(c *gin.Context) {
+	var plans []models.PMPlan
+	query := database.DB.Order("no_mc asc")
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	query.Find(&plans)
+	c.JSON(http.StatusOK, gin.H{"total": len(plans), "data": plans})
+}
+
+// POST: /api/pm/plans - Buat rencana PM & tugas pertamanya
+func CreatePMPlan(c *gin.Context) {
+	var input struct {
+		NoMC          string   `json:"no_mc" binding:"required"`
+		Nama          string   `json:"nama" binding:"required"`
+		TriggerType   string   `json:"trigger_type" binding:"required,oneof=CALENDAR RUN_HOURS SHOTS"`
+		IntervalValue float64  `json:"interval_value" binding:"required,gt=0"`
+		Critical      bool     `json:"critical"`
+		Checklist     []string `json:"checklist"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	plan := models.PMPlan{
+		NoMC:          input.NoMC,
+		Nama:          input.Nama,
+		TriggerType:   input.TriggerType,
+		IntervalValue: input.IntervalValue,
+		Critical:      input.Critical,
+		Checklist:     input.Checklist,
+		Aktif:         true,
+	}
+
+	// Plan berbasis counter mulai dihitung dari counter mesin saat ini
+	if plan.TriggerType != "CALENDAR" {
+		runHours, shots := machineCounters(plan.NoMC)
+		plan.LastDoneValue = counterFor(plan.TriggerType, runHours, shots)
+	}
+
+	if err := database.DB.Create(&plan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan rencana PM"})
+		return
+	}
+	task, _ := generateNextPMTask(plan)
+
+	recordAudit(c, "CREATE_PM_PLAN", fmt.Sprintf("MC %s: %s", plan.NoMC, plan.Nama))
+	c.JSON(http.StatusCreated, gin.H{"message": "Rencana PM dibuat", "data": plan, "task": task})
+}
+
+// PUT: /api/pm/plans/:id - Ubah interval / checklist / aktif
+func UpdatePMPlan(c *gin.Context) {
+	var plan models.PMPlan
+	if err := database.DB.First(&plan, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rencana PM tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Nama          string   `json:"nama"`
+		IntervalValue float64  `json:"interval_value"`
+		Critical      *bool    `json:"critical"`
+		Checklist     []string `json:"checklist"`
+		Aktif         *bool    `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Nama != "" {
+		plan.Nama = input.Nama
+	}
+	if input.IntervalValue > 0 {
+		plan.IntervalValue = input.IntervalValue
+	}
+	if input.Critical != nil {
+		plan.Critical = *input.Critical
+	}
+	if input.Checklist != nil {
+		plan.Checklist = input.Checklist
+	}
+	if input.Aktif != nil {
+		plan.Aktif = *input.Aktif
+	}
+
+	// Tugas OPEN lama mengikuti interval / critical lama: dibatalkan lalu dibuat ulang dari plan baru
+	var task *models.PMTask
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&plan).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PMTask{}).Where("plan_id = ? AND status = ?", plan.ID, "OPEN").
+			Updates(map[string]interface{}{"status": "CANCELLED", "note": "Plan diubah"}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan rencana PM"})
+		return
+	}
+	if plan.Aktif {
+		if task, err = generateNextPMTask(plan); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Rencana PM tersimpan, tapi gagal membuat tugas PM baru"})
+			return
+		}
+	}
+
+	recordAudit(c, "UPDATE_PM_PLAN", fmt.Sprintf("#%d %s", plan.ID, plan.Nama))
+	c.JSON(http.StatusOK, gin.H{"message": "Rencana PM diupdate", "data": plan, "task": task})
+}
+
+// POST: /api/pm/generate - Pastikan setiap plan aktif punya satu tugas OPEN
+func GeneratePMTasks(c *gin.Context) {
+	var plans []models.PMPlan
+	database.DB.Where("aktif = ?", true).Find(&plans)
+
+	created := []models.PMTask{}
+	for _, p := range plans {
+		if task, err := generateNextPMTask(p); err == nil && task != nil {
+			created = append(created, *task)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Generate tugas PM selesai", "created": len(created), "data": created})
+}
+
+// GET: /api/pm/tasks?no_mc=04A&status=OVERDUE
+func GetPMTasks(c *gin.Context) {
+	query := database.DB.Order("no_mc asc, created_at asc")
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	if c.Query("include_done") != "true" {
+		query = query.Where("status = ?", "OPEN")
+	}
+
+	var tasks []models.PMTask
+	query.Find(&tasks)
+
+	// Counter mesin dihitung sekali per mesin
+	type counter struct{ hours, shots float64 }
+	counters := make(map[string]counter)
+	filter := c.Query("status")
+
+	result := []PMTaskStatus{}
+	for _, t := range tasks {
+		cnt, ok := counters[t.NoMC]
+		if !ok {
+			cnt.hours, cnt.shots = machineCounters(t.NoMC)
+			counters[t.NoMC] = cnt
+		}
+		st := evaluatePMTask(t, cnt.hours, cnt.shots)
+		if filter == "" || st.DueStatus == filter {
+			result = append(result, st)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": len(result), "data": result})
+}
+
+// POST: /api/pm/tasks/:id/complete - Teknisi menyelesaikan PM
+func CompletePMTask(c *gin.Context) {
+	var task models.PMTask
+	if err := database.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tugas PM tidak ditemukan"})
+		return
+	}
+	if task.Status == "DONE" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tugas PM sudah selesai"})
+		return
+	}
+
+	var input struct {
+		TechnicianNIK string                   `json:"technician_nik"`
+		Checklist     []models.ChecklistResult `json:"checklist"`
+		Note          string                   `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.TechnicianNIK == "" {
+		input.TechnicianNIK = currentNIK(c)
+	}
+
+	var plan models.PMPlan
+	if err := database.DB.First(&plan, task.PlanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rencana PM tidak ditemukan"})
+		return
+	}
+
+	// Semua item checklist plan wajib diisi
+	done := make(map[string]bool)
+	for _, r := range input.Checklist {
+		done[r.Item] = true
+	}
+	for _, item := range plan.Checklist {
+		if !done[item] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist belum lengkap: " + item})
+			return
+		}
+	}
+
+	now := time.Now()
+	task.Status = "DONE"
+	task.CompletedAt = &now
+	task.TechnicianNIK = input.TechnicianNIK
+	task.ChecklistResult = input.Checklist
+	task.Note = input.Note
+	database.DB.Save(&task)
+
+	runHours, shots := machineCounters(plan.NoMC)
+	plan.LastDoneAt = &now
+	plan.LastDoneValue = counterFor(plan.TriggerType, runHours, shots)
+	database.DB.Save(&plan)
+
+	var next *models.PMTask
+	if plan.Aktif {
+		next, _ = generateNextPMTask(plan)
+	}
+
+	recordAudit(c, "COMPLETE_PM",
+		fmt.Sprintf("MC %s: %s oleh %s", task.NoMC, task.Nama, task.TechnicianNIK))
+	c.JSON(http.StatusOK, gin.H{"message": "PM selesai", "data": task, "next": next})
+}
+
+// GET: /api/pm/check/:no_mc - Cek apakah mesin boleh dijalankan
+func CheckMachinePM(c *gin.Context) {
+	noMC := c.Param("no_mc")
+	blocking, warnings := checkMachinePM(noMC)
+
+	c.JSON(http.StatusOK, gin.H{
+		"no_mc":     noMC,
+		"can_start": len(blocking) == 0,
+		"blocking":  blocking,
+		"warnings":  warnings,
+	})
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper: Parse rentang tanggal dari query (?from=YYYY-MM-DD&to=YYYY-MM-DD), default 30 hari terakhir
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	from, to := today.AddDate(0, 0, -29), today
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("format from salah (gunakan YYYY-MM-DD)")
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("format to salah (gunakan YYYY-MM-DD)")
+		}
+		to = t
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("tanggal to harus setelah from")
+	}
+	// to dibuat eksklusif (akhir hari)
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// POST: /api/mold-changes/start - Operator mulai ganti mold
+func StartMoldChange(c *gin.Context) {
+	var input struct {
+		NoMC     string `json:"no_mc" binding:"required"`
+		FromMold string `json:"from_mold"`
+		ToMold   string `json:"to_mold" binding:"required"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var open int64
+	database.DB.Model(&models.MoldChange{}).Where("no_mc = ? AND finished_at IS NULL", input.NoMC).Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Masih ada pergantian mold yang belum selesai di mesin " + input.NoMC})
+		return
+	}
+
+	// Mold asal default = mold tujuan dari pergantian terakhir di mesin ini
+	if input.FromMold == "" {
+		var last models.MoldChange
+		if err := database.DB.Where("no_mc = ?", input.NoMC).Order("started_at desc").First(&last).Error; err == nil {
+			input.FromMold = last.ToMold
+		}
+	}
+
+	change := models.MoldChange{
+		NoMC:        input.NoMC,
+		FromMold:    input.FromMold,
+		ToMold:      input.ToMold,
+		StartedAt:   time.Now(),
+		OperatorNIK: currentNIK(c),
+		Source:      "MANUAL",
+		Note:        input.Note,
+	}
+	if err := database.DB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan pergantian mold"})
+		return
+	}
+
+	recordAudit(c, "START_MOLD_CHANGE",
+		fmt.Sprintf("MC %s: %s -> %s", change.NoMC, change.FromMold, change.ToMold))
+	c.JSON(http.StatusCreated, gin.H{"message": "Pergantian mold dimulai", "data": change})
+}
+
+// POST: /api/mold-changes/:id/finish - Mold terpasang, mesin siap produksi
+func FinishMoldChange(c *gin.Context) {
+	var change models.MoldChange
+	if err := database.DB.First(&change, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data pergantian mold tidak ditemukan"})
+		return
+	}
+	if change.FinishedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Pergantian mold sudah selesai"})
+		return
+	}
+
+	now := time.Now()
+	change.FinishedAt = &now
+	change.SetupMinutes = now.Sub(change.StartedAt).Minutes()
+	database.DB.Save(&change)
+
+	recordAudit(c, "FINISH_MOLD_CHANGE",
+		fmt.Sprintf("MC %s: %.0f menit", change.NoMC, change.SetupMinutes))
+	c.JSON(http.StatusOK, gin.H{"message": "Pergantian mold selesai", "data": change})
+}
+
+// POST: /api/mold-changes/infer?from=&to=&no_mc= - Deteksi pergantian mold dari baris LWP berurutan
+func InferMoldChanges(c *gin.Context) {
+	if !isDBConnected(c) {
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	noMC := c.Query("no_mc")
+
+	type lwpRow struct {
+		NoMC     string
+		MoldCode string
+		NPK      string
+		Nama     string
+		Mulai    time.Time
+		Selesai  time.Time
+	}
+	var rows []lwpRow
+	query := `
+		SELECT
+			t.noMC AS no_mc,
+			t.moldCode AS mold_code,
+			COALESCE(t.NPK, '') AS npk,
+			COALESCE(t.nama, '') AS nama,
+			TIMESTAMP(t.tanggal, t.MULAI) AS mulai,
+			TIMESTAMP(t.tanggal, t.SELESAI) AS selesai
+		FROM vtrx_lwp_prs t
+		WHERE t.tanggal >= ? AND t.tanggal < ?
+			AND (? = '' OR t.noMC = ?)
+			AND t.MULAI IS NOT NULL AND t.SELESAI IS NOT NULL
+		ORDER BY t.noMC, t.tanggal, t.MULAI
+	`
+	if err := database.MySQL.Raw(query, from.Format("2006-01-02"), to.Format("2006-01-02"), noMC, noMC).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca LWP: " + err.Error()})
+		return
+	}
+
+	created := []models.MoldChange{}
+	identities := map[string]models.OperatorIdentity{}
+	for i := 1; i < len(rows); i++ {
+		prev, cur := rows[i-1], rows[i]
+		if prev.NoMC != cur.NoMC || prev.MoldCode == cur.MoldCode || !cur.Mulai.After(prev.Selesai) {
+			continue
+		}
+
+		// Jangan dobel jika sudah pernah di-infer / dicatat manual di waktu yang sama
+		var exists int64
+		database.DB.Model(&models.MoldChange{}).
+			Where("no_mc = ? AND started_at >= ? AND started_at <= ?", cur.NoMC, prev.Selesai.Add(-30*time.Minute), cur.Mulai).
+			Count(&exists)
+		if exists > 0 {
+			continue
+		}
+
+		finished := cur.Mulai
+		change := models.MoldChange{
+			NoMC:         cur.NoMC,
+			FromMold:     prev.MoldCode,
+			ToMold:       cur.MoldCode,
+			StartedAt:    prev.Selesai,
+			FinishedAt:   &finished,
+			SetupMinutes: cur.Mulai.Sub(prev.Selesai).Minutes(),
+			OperatorNIK:  resolveRowOperator(identities, cur.NPK, cur.Nama).NIK,
+			Source:       "INFERRED",
+		}
+		if err := database.DB.Create(&change).Error; err == nil {
+			created = append(created, change)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deteksi pergantian mold selesai", "created": len(created), "data": created})
+}
+
+// GET: /api/mold-changes?from=&to=&no_mc=
+func GetMoldChanges(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Where("started_at >= ? AND started_at < ?", from, to)
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+
+	var changes []models.MoldChange
+	query.Order("started_at desc").Find(&changes)
+	c.JSON(http.StatusOK, gin.H{"total": len(changes), "data": changes})
+}
+
+// Ringkasan waktu setup (per mesin / per pasangan mold)
+type SetupSummary struct {
+	Key        string  `json:"key"`
+	NoMC       string  `json:"no_mc,omitempty"`
+	FromMold   string  `json:"from_mold,omitempty"`
+	ToMold     string  `json:"to_mold,omitempty"`
+	Count      int     `json:"count"`
+	AvgMinutes float64 `json:"avg_minutes"`
+	MinMinutes float64 `json:"min_minutes"`
+	MaxMinutes float64 `json:"max_minutes"`
+}
+
+// Helper: Akumulasi durasi setup ke ringkasan
+func addSetup(m map[string]*SetupSummary, key string, base SetupSummary, minutes float64) {
+	s, ok := m[key]
+	if !ok {
+		base.Key = key
+		base.MinMinutes = minutes
+		s = &base
+		m[key] = s
+	}
+	s.AvgMinutes = (s.AvgMinutes*float64(s.Count) + minutes) / float64(s.Count+1)
+	s.Count++
+	if minutes < s.MinMinutes {
+		s.MinMinutes = minutes
+	}
+	if minutes > s.MaxMinutes {
+		s.MaxMinutes = minutes
+	}
+}
+
+// GET: /api/mold-changes/report?from=&to= - Rata-rata waktu dandori per mesin & pasangan mold (SMED)
+func GetMoldChangeReport(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var changes []models.MoldChange
+	database.DB.Where("started_at >= ? AND started_at < ? AND finished_at IS NOT NULL", from, to).Find(&changes)
+
+	perMachine := make(map[string]*SetupSummary)
+	perPair := make(map[string]*SetupSummary)
+	for _, ch := range changes {
+		addSetup(perMachine, ch.NoMC, SetupSummary{NoMC: ch.NoMC}, ch.SetupMinutes)
+		addSetup(perPair, ch.NoMC+"|"+ch.FromMold+"->"+ch.ToMold,
+			SetupSummary{NoMC: ch.NoMC, FromMold: ch.FromMold, ToMold: ch.ToMold}, ch.SetupMinutes)
+	}
+
+	toSlice := func(m map[string]*SetupSummary) []SetupSummary {
+		out := []SetupSummary{}
+		for _, s := range m {
+			out = append(out, *s)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].AvgMinutes > out[j].AvgMinutes })
+		return out
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from.Format("2006-01-02"),
+		"to":          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"total":       len(changes),
+		"per_machine": toSlice(perMachine),
+		"per_pair":    toSlice(perPair),
+	})
+}
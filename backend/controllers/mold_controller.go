@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper: Item code untuk satu mold (master mold, fallback v_stdlot). Kosong jika tidak diketahui.
+func itemCodeForMold(moldCode string) string {
+	if moldCode == "" {
+		return ""
+	}
+	var molds []models.Mold
+	database.DB.Where("mold_code = ?", moldCode).Limit(1).Find(&molds)
+	if len(molds) > 0 && molds[0].ItemCode != "" {
+		return molds[0].ItemCode
+	}
+	if database.MySQL == nil {
+		return ""
+	}
+	var itemCode string
+	database.MySQL.Raw("SELECT itemCode FROM v_stdlot WHERE moldCode = ? LIMIT 1", moldCode).Scan(&itemCode)
+	return itemCode
+}
+
+// Status mold lengkap dengan counter shot hasil hitung dari LWP
+type MoldStatus struct {
+	models.Mold
+	TotalPcs           int     `json:"total_pcs"`    // SUM(Total) di vtrx_lwp_prs
+	Shots              int     `json:"shots"`        // Offset + total pcs / cavity
+	LifePercent        float64 `json:"life_percent"` // Shots / MaxShot
+	RemainingShots     int     `json:"remaining_shots"`
+	ShotsSinceCleaning int     `json:"shots_since_cleaning"`
+	ShotsSincePM       int     `json:"shots_since_pm"`
+	CleaningDue        bool    `json:"cleaning_due"`
+	PMDue              bool    `json:"pm_due"`
+	NearEndOfLife      bool    `json:"near_end_of_life"`
+}
+
+// Helper: Total pcs per mold dari data LWP (MySQL). Map kosong jika MySQL tidak terhubung.
+func moldTotalPcs(moldCode string) map[string]int {
+	totals := make(map[string]int)
+	if database.MySQL == nil {
+		return totals
+	}
+
+	type row struct {
+		MoldCode string
+		Total    int
+	}
+	var rows []row
+	query := `
+		SELECT moldCode AS mold_code, COALESCE(SUM(Total), 0) AS total
+		FROM vtrx_lwp_prs
+		WHERE (? = '' OR moldCode = ?)
+		GROUP BY moldCode
+	`
+	database.MySQL.Raw(query, moldCode, moldCode).Scan(&rows)
+	for _, r := range rows {
+		totals[r.MoldCode] = r.Total
+	}
+	return totals
+}
+
+// Helper: Hitung status shot mold. nearLife = ambang % umur (misal 90)
+func buildMoldStatus(m models.Mold, totalPcs int, nearLife float64) MoldStatus {
+	cavity := m.Cavity
+	if cavity <= 0 {
+		cavity = 1
+	}
+
+	st := MoldStatus{Mold: m, TotalPcs: totalPcs}
+	st.Shots = m.ShotOffset + totalPcs/cavity
+	st.ShotsSinceCleaning = st.Shots - m.LastCleaningShot
+	st.ShotsSincePM = st.Shots - m.LastPMShot
+
+	if m.MaxShot > 0 {
+		st.LifePercent = float64(st.Shots) / float64(m.MaxShot) * 100
+		st.RemainingShots = m.MaxShot - st.Shots
+		st.NearEndOfLife = st.LifePercent >= nearLife
+	}
+	st.CleaningDue = m.CleaningInterval > 0 && st.ShotsSinceCleaning >= m.CleaningInterval
+	st.PMDue = m.PMInterval > 0 && st.ShotsSincePM >= m.PMInterval
+	return st
+}
+
+// Helper: Status shot satu mold (dipakai juga oleh modul lain)
+func getMoldStatus(moldCode string) (MoldStatus, error) {
+	var mold models.Mold
+	if err := database.DB.Where("mold_code = ?", moldCode).First(&mold).Error; err != nil {
+		return MoldStatus{}, fmt.Errorf("mold %s tidak terdaftar", moldCode)
+	}
+	return buildMoldStatus(mold, moldTotalPcs(moldCode)[moldCode], 90), nil
+}
+
+// GET: /api/molds - Daftar mold beserta counter shot
+func GetMolds(c *gin.Context) {
+	var molds []models.Mold
+	database.DB.Order("mold_code asc").Find(&molds)
+
+	totals := moldTotalPcs("")
+	result := []MoldStatus{}
+	for _, m := range molds {
+		result = append(result, buildMoldStatus(m, totals[m.MoldCode], 90))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": len(result), "data": result})
+}
+
+// GET: /api/molds/:code
+func GetMold(c *gin.Context) {
+	status, err := getMoldStatus(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var history []models.MoldMaintenance
+	database.DB.Where("mold_code = ?", status.MoldCode).Order("created_at desc").Limit(20).Find(&history)
+
+	c.JSON(http.StatusOK, gin.H{"data": status, "maintenance": history})
+}
+
+// GET: /api/molds/alerts?threshold=90 - Mold mendekati akhir umur / jatuh tempo cleaning / PM
+func GetMoldAlerts(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "90"), 64)
+	if err != nil {
+		threshold = 90
+	}
+
+	var molds []models.Mold
+	database.DB.Where("status <> ?", "SCRAPPED").Find(&molds)
+
+	totals := moldTotalPcs("")
+	alerts := []MoldStatus{}
+	for _, m := range molds {
+		st := buildMoldStatus(m, totals[m.MoldCode], threshold)
+		if st.NearEndOfLife || st.CleaningDue || st.PMDue {
+			alerts = append(alerts, st)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threshold": threshold, "total": len(alerts), "data": alerts})
+}
+
+// Struct input master mold
+type MoldInput struct {
+	MoldCode         string  `json:"mold_code" binding:"required"`
+	MoldName         string  `json:"mold_name"`
+	ItemCode         string  `json:"item_code"`
+	Cavity           int     `json:"cavity" binding:"required,min=1"`
+	StdCycleSec      float64 `json:"std_cycle_sec"`
+	MaxShot          int     `json:"max_shot"`
+	CleaningInterval int     `json:"cleaning_interval"`
+	PMInterval       int     `json:"pm_interval"`
+	ShotOffset       int     `json:"shot_offset"`
+	Status           string  `json:"status" binding:"omitempty,oneof=ACTIVE MAINTENANCE SCRAPPED"`
+}
+
+// POST: /admin/molds
+func CreateMold(c *gin.Context) {
+	var input MoldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	mold := models.Mold{
+		MoldCode:         input.MoldCode,
+		MoldName:         input.MoldName,
+		ItemCode:         input.ItemCode,
+		Cavity:           input.Cavity,
+		StdCycleSec:      input.StdCycleSec,
+		MaxShot:          input.MaxShot,
+		CleaningInterval: input.CleaningInterval,
+		PMInterval:       input.PMInterval,
+		ShotOffset:       input.ShotOffset,
+		Status:           "ACTIVE",
+	}
+
+	if err := database.DB.Create(&mold).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan mold (kode mungkin sudah ada)"})
+		return
+	}
+
+	recordAudit(c, "CREATE_MOLD", mold.MoldCode)
+	c.JSON(http.StatusCreated, gin.H{"message": "Mold berhasil didaftarkan", "data": mold})
+}
+
+// PUT: /admin/molds/:code
+func UpdateMold(c *gin.Context) {
+	var mold models.Mold
+	if err := database.DB.Where("mold_code = ?", c.Param("code")).First(&mold).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mold tidak ditemukan"})
+		return
+	}
+
+	var input MoldInput
+	input.MoldCode = mold.MoldCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	mold.MoldName = input.MoldName
+	mold.ItemCode = input.ItemCode
+	mold.Cavity = input.Cavity
+	mold.StdCycleSec = input.StdCycleSec
+	mold.MaxShot = input.MaxShot
+	mold.CleaningInterval = input.CleaningInterval
+	mold.PMInterval = input.PMInterval
+	mold.ShotOffset = input.ShotOffset
+	if input.Status != "" {
+		mold.Status = input.Status
+	}
+
+	if err := database.DB.Save(&mold).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan mold"})
+		return
+	}
+	recordAudit(c, "UPDATE_MOLD", mold.MoldCode)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mold berhasil diupdate", "data": mold})
+}
+
+// POST: /admin/molds/import - Daftarkan mold dari v_stdlot yang belum ada di registry
+func ImportMoldsFromStdLot(c *gin.Context) {
+	if !isDBConnected(c) {
+		return
+	}
+
+	type stdMold struct {
+		MoldCode string
+		MoldName string
+		ItemCode string
+	}
+	var rows []stdMold
+	query := `
+		SELECT moldCode AS mold_code, MAX(moldName) AS mold_name, MAX(itemCode) AS item_code
+		FROM v_stdlot
+		WHERE moldCode IS NOT NULL AND moldCode <> ''
+		GROUP BY moldCode
+	`
+	if err := database.MySQL.Raw(query).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membaca v_stdlot: " + err.Error()})
+		return
+	}
+
+	created := 0
+	for _, r := range rows {
+		mold := models.Mold{MoldCode: r.MoldCode, MoldName: r.MoldName, ItemCode: r.ItemCode, Cavity: 1, Status: "ACTIVE"}
+		res := database.DB.Where("mold_code = ?", r.MoldCode).FirstOrCreate(&mold)
+		if res.Error == nil && res.RowsAffected > 0 {
+			created++
+		}
+	}
+
+	recordAudit(c, "IMPORT_MOLD", fmt.Sprintf("%d mold baru", created))
+	c.JSON(http.StatusOK, gin.H{"message": "Import mold selesai (cek cavity & umur mold)", "created": created})
+}
+
+// POST: /api/molds/:code/maintenance - Catat cleaning / PM, reset counter shot terkait
+func RecordMoldMaintenance(c *gin.Context) {
+	status, err := getMoldStatus(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input struct {
+		Type string `json:"type" binding:"required,oneof=CLEANING PM"`
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type harus CLEANING atau PM"})
+		return
+	}
+
+	record := models.MoldMaintenance{
+		MoldCode: status.MoldCode,
+		Type:     input.Type,
+		ShotAt:   status.Shots,
+		DoneBy:   currentNIK(c),
+		Note:     input.Note,
+	}
+	column := "last_cleaning_shot"
+	if input.Type == "PM" {
+		column = "last_pm_shot"
+	}
+	// Riwayat & reset counter harus tersimpan bersama
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Mold{}).Where("mold_code = ?", status.MoldCode).Update(column, status.Shots).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan maintenance mold"})
+		return
+	}
+
+	recordAudit(c, "MOLD_"+input.Type, fmt.Sprintf("%s @ %d shot", status.MoldCode, status.Shots))
+	c.JSON(http.StatusCreated, gin.H{"message": "Maintenance mold tercatat", "data": record})
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET: /api/notifications/preferences - Preferensi notifikasi milik user login
+func GetMyNotificationPreferences(c *gin.Context) {
+	var prefs []models.NotificationPreference
+	database.DB.Where("nik = ?", currentNIK(c)).Find(&prefs)
+	c.JSON(http.StatusOK, gin.H{"data": prefs})
+}
+
+// PUT: /api/notifications/preferences - Simpan preferensi per channel (satu baris per channel)
+func SaveMyNotificationPreference(c *gin.Context) {
+	var input struct {
+		Channel    string   `json:"channel" binding:"required,oneof=email webhook chat"`
+		Target     string   `json:"target"`
+		EventTypes []string `json:"event_types"`
+		Aktif      *bool    `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+	if input.Channel != "email" && input.Target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target URL wajib diisi untuk channel " + input.Channel})
+		return
+	}
+	if err := notify.ValidateTarget(input.Channel, input.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target tidak diizinkan: " + err.Error()})
+		return
+	}
+
+	nik := currentNIK(c)
+	var pref models.NotificationPreference
+	database.DB.Where("nik = ? AND channel = ?", nik, input.Channel).FirstOrInit(&pref)
+	pref.NIK = nik
+	pref.Role = currentRole(c)
+	pref.Channel = input.Channel
+	pref.Target = input.Target
+	pref.EventTypes = input.EventTypes
+	pref.Aktif = input.Aktif == nil || *input.Aktif
+
+	if err := database.DB.Save(&pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan preferensi"})
+		return
+	}
+	if !pref.Aktif {
+		database.DB.Model(&pref).Update("aktif", false)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preferensi notifikasi disimpan", "data": pref})
+}
+
+// POST: /admin/notifications/test - Kirim notifikasi tes langsung (tanpa antrian, hanya admin)
+func SendTestNotification(c *gin.Context) {
+	var input struct {
+		Channel string `json:"channel" binding:"required,oneof=email webhook chat"`
+		Target  string `json:"target" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	if err := notify.ValidateTarget(input.Channel, input.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target tidak diizinkan: " + err.Error()})
+		return
+	}
+
+	job, err := notify.SendNow("test", currentNIK(c), input.Channel, input.Target, map[string]interface{}{"target": input.Target})
+	if job == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal kirim: " + err.Error(), "data": job})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifikasi tes terkirim", "data": job})
+}
+
+// GET: /admin/notification-templates
+func GetNotificationTemplates(c *gin.Context) {
+	var templates []models.NotificationTemplate
+	database.DB.Order("code asc").Find(&templates)
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+// PUT: /admin/notification-templates/:code
+func SaveNotificationTemplate(c *gin.Context) {
+	var input struct {
+		Subject string `json:"subject" binding:"required"`
+		Body    string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject dan body wajib diisi"})
+		return
+	}
+
+	code := c.Param("code")
+	var tpl models.NotificationTemplate
+	database.DB.Where("code = ?", code).FirstOrInit(&tpl)
+	tpl.Code = code
+	tpl.Subject = input.Subject
+	tpl.Body = input.Body
+
+	if err := database.DB.Save(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan template"})
+		return
+	}
+
+	recordAudit(c, "UPDATE_NOTIFICATION_TEMPLATE", code)
+	c.JSON(http.StatusOK, gin.H{"message": "Template disimpan", "data": tpl})
+}
+
+// GET: /admin/notifications?status=FAILED - Isi antrian pengiriman
+func GetNotificationJobs(c *gin.Context) {
+	query := database.DB.Order("created_at desc").Limit(200)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var jobs []models.NotificationJob
+	query.Find(&jobs)
+	c.JSON(http.StatusOK, gin.H{"total": len(jobs), "data": jobs})
+}
+
+// POST: /admin/notifications/:id/retry - Kirim ulang job yang gagal
+func RetryNotificationJob(c *gin.Context) {
+	var job models.NotificationJob
+	if err := database.DB.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job tidak ditemukan"})
+		return
+	}
+	if job.Status == "SENT" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Job sudah terkirim"})
+		return
+	}
+
+	job.Status = "PENDING"
+	job.Attempts = 0
+	job.NextAttemptAt = time.Now()
+	database.DB.Save(&job)
+
+	recordAudit(c, "RETRY_NOTIFICATION", fmt.Sprintf("#%d", job.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "Job dijadwalkan ulang", "data": job})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper: Resolusi NIK/NPK/nama operator dari parameter request (kirim error JSON jika gagal)
+func resolveOperatorParam(c *gin.Context, key string) (models.OperatorIdentity, bool) {
+	identity, err := database.ResolveOperator(key)
+	switch {
+	case err == nil:
+		return identity, true
+	case errors.Is(err, database.ErrOperatorAmbiguous):
+		c.JSON(http.StatusConflict, gin.H{"error": "Nama operator '" + key + "' dipakai lebih dari satu karyawan, gunakan NIK"})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operator '" + key + "' tidak ditemukan"})
+	}
+	return identity, false
+}
+
+// Helper: Petakan NPK / nama dari baris LWP ERP ke identitas operator (cache per request).
+// Jika tidak bisa di-resolve, NIK diisi NPK apa adanya (nama tidak pernah dipakai sebagai NIK).
+func resolveRowOperator(cache map[string]models.OperatorIdentity, npk, nama string) models.OperatorIdentity {
+	key := npk
+	if key == "" {
+		key = nama
+	}
+	if key == "" {
+		return models.OperatorIdentity{}
+	}
+	if identity, ok := cache[key]; ok {
+		return identity
+	}
+	identity, err := database.ResolveOperator(key)
+	if err != nil {
+		identity = models.OperatorIdentity{NIK: npk, Nama: nama}
+	}
+	cache[key] = identity
+	return identity
+}
+
+// Helper: Nama tampilan operator (fallback ke NIK jika tidak ditemukan)
+func operatorName(nik string) string {
+	if identity, err := database.ResolveOperator(nik); err == nil {
+		return identity.Nama
+	}
+	return nik
+}
+
+// GET: /api/operators/resolve?q= - Cek identitas operator dari NIK, NPK atau nama
+func ResolveOperatorIdentity(c *gin.Context) {
+	identity, ok := resolveOperatorParam(c, c.Query("q"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// GET: /api/admin/operator-aliases?nik=
+func GetOperatorAliases(c *gin.Context) {
+	query := database.DB.Order("nik asc, kind asc")
+	if nik := c.Query("nik"); nik != "" {
+		query = query.Where("nik = ?", nik)
+	}
+	var aliases []models.OperatorAlias
+	query.Find(&aliases)
+	c.JSON(http.StatusOK, gin.H{"data": aliases})
+}
+
+// POST: /api/admin/operator-aliases - Petakan NPK lama / nama lama ke NIK
+func CreateOperatorAlias(c *gin.Context) {
+	var input struct {
+		NIK   string `json:"nik" binding:"required"`
+		Kind  string `json:"kind" binding:"required"`
+		Alias string `json:"alias" binding:"required"`
+		Note  string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nik, kind dan alias wajib diisi"})
+		return
+	}
+	input.Kind = strings.ToUpper(input.Kind)
+	if input.Kind != "NPK" && input.Kind != "NAMA" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind harus NPK atau NAMA"})
+		return
+	}
+
+	// NIK tujuan harus ada di tm_employ
+	if _, ok := resolveOperatorParam(c, input.NIK); !ok {
+		return
+	}
+
+	alias := models.OperatorAlias{
+		NIK:       input.NIK,
+		Kind:      input.Kind,
+		Alias:     strings.TrimSpace(input.Alias),
+		Note:      input.Note,
+		CreatedBy: currentNIK(c),
+	}
+	if err := database.DB.Create(&alias).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias sudah terdaftar: " + alias.Alias})
+		return
+	}
+	database.InvalidateOperatorCache()
+
+	recordAudit(c, "CREATE_OPERATOR_ALIAS", fmt.Sprintf("%s %s -> %s", alias.Kind, alias.Alias, alias.NIK))
+	c.JSON(http.StatusCreated, gin.H{"message": "Alias operator ditambahkan", "data": alias})
+}
+
+// DELETE: /api/admin/operator-aliases/:id
+func DeleteOperatorAlias(c *gin.Context) {
+	var alias models.OperatorAlias
+	if err := database.DB.First(&alias, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias tidak ditemukan"})
+		return
+	}
+	// Hapus permanen agar alias yang sama bisa didaftarkan ulang (unique index)
+	database.DB.Unscoped().Delete(&alias)
+	database.InvalidateOperatorCache()
+
+	recordAudit(c, "DELETE_OPERATOR_ALIAS", fmt.Sprintf("%s %s -> %s", alias.Kind, alias.Alias, alias.NIK))
+	c.JSON(http.StatusOK, gin.H{"message": "Alias operator dihapus"})
+}
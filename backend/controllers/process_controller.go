@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/process"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helper: Total output (pcs) per proses pada satu tanggal, dari sumber data masing-masing proses
+func processOutput(tanggal string) map[string]int64 {
+	output := map[string]int64{}
+	if database.MySQL == nil {
+		return output
+	}
+	for _, def := range process.All() {
+		var total int64
+		query := fmt.Sprintf("SELECT COALESCE(SUM(t.Total), 0) FROM %s t WHERE t.tanggal = ?", def.Source())
+		if err := database.MySQL.Raw(query, tanggal).Scan(&total).Error; err != nil {
+			fmt.Printf("[PROCESS] Gagal hitung output %s: %v\n", def.Code, err)
+			continue
+		}
+		output[def.Code] = total
+	}
+	return output
+}
+
+// GET: /api/processes - Daftar proses & sumber datanya (tabel, kolom, jenis reject)
+func GetProcesses(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": process.All()})
+}
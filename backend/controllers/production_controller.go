@@ -5,8 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"factory-api/database"
 	"factory-api/models"
+	"factory-api/process"
+	"factory-api/realtime"
 	"time"
 	"fmt"
+	"errors"
+
+	"gorm.io/gorm"
 )
 
 // GET: Melihat status mesin Cutting
@@ -27,25 +32,45 @@ func GetPressingStatus(c *gin.Context) {
 	})
 }
 
+// Struct input opsional saat menjalankan mesin
+type StartMachineInput struct {
+	NoMC string `json:"no_mc"`
+}
+
 // POST: Menjalankan mesin Cutting (Hanya Role Cutting & Admin)
 func StartCutting(c *gin.Context) {
-	// Ambil data user dari JWT claims (kita perlu update middleware sedikit untuk menyimpan userID)
-	userID, _ := c.Get("userID")
-	username, _ := c.Get("username")
+	var input StartMachineInput
+	c.ShouldBindJSON(&input)
 
-	// Simpan ke Audit Log
-	database.RecordActivity(uint(userID.(float64)), username.(string), "START_CUTTING_MACHINE", c.Request.URL.Path)
+	// Mesin dengan PM kritis overdue tidak boleh dijalankan
+	blocked, warnings := abortIfPMBlocked(c, input.NoMC)
+	if blocked {
+		return
+	}
+
+	// Simpan ke Audit Log (user_id di token berisi NIK, jadi pakai 0)
+	recordAudit(c, "START_CUTTING_MACHINE", c.Request.URL.Path)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Mesin Cutting berhasil dijalankan!",
-		"logged":  true,
+		"message":  "Mesin Cutting berhasil dijalankan!",
+		"logged":   true,
+		"warnings": warnings,
 	})
 }
 
 // POST: Menjalankan mesin Pressing (Hanya Role Pressing & Admin)
 func StartPressing(c *gin.Context) {
+	var input StartMachineInput
+	c.ShouldBindJSON(&input)
+
+	blocked, warnings := abortIfPMBlocked(c, input.NoMC)
+	if blocked {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Mesin Pressing berhasil dijalankan!",
+		"message":  "Mesin Pressing berhasil dijalankan!",
+		"warnings": warnings,
 	})
 }
 
@@ -60,6 +85,7 @@ type DashboardData struct {
 	CuttingOutput   int64               `json:"cuttingOutput"`
 	PressingOutput  int64               `json:"pressingOutput"`
 	FinishingOutput int64               `json:"finishingOutput"`
+	ProcessOutput   map[string]int64    `json:"processOutput"` // Output hari ini (pcs) per proses
 	Activities      []ActivityResponse  `json:"activities"`
 }
 
@@ -83,6 +109,9 @@ func GetDashboardStats(c *gin.Context) {
 	var pressingCount int64
 	database.DB.Model(&models.WorkOrder{}).Where("status = ?", "PRESSING").Count(&pressingCount)
 
+	// Output pcs hari ini per proses (finishing belum punya status WO, jadi diambil dari sumber proses FIN)
+	perProcess := processOutput(time.Now().Format("2006-01-02"))
+
 	// 3. Ambil 5 Aktivitas Terakhir (AuditLog)
 	var logs []models.AuditLog
 	// Preload User jika ada relasi, tapi karena di AuditLog sudah simpan Username, langsung saja
@@ -106,7 +135,8 @@ func GetDashboardStats(c *gin.Context) {
 		ActiveShift:     "Shift 1 (Pagi)",
 		CuttingOutput:   cuttingCount,
 		PressingOutput:  pressingCount,
-		FinishingOutput: 0,
+		FinishingOutput: perProcess["FIN"],
+		ProcessOutput:   perProcess,
 		Activities:      activities,
 	})
 }
@@ -120,12 +150,17 @@ func RecordPressingCycle(c *gin.Context) {
 		return
 	}
 
-	// Opsi Tambahan: Jika frontend tidak mengirim nama_operator, 
-	// kita bisa ambil otomatis dari Token login (Context)
-	if input.NamaOperator == "" {
-		username, _ := c.Get("username")
-		input.NamaOperator = username.(string)
+	// Operator diambil dari token login jika frontend tidak mengirim NIK,
+	// nama_operator selalu nama tampilan dari tm_employ
+	if input.NIK == "" {
+		input.NIK = currentNIK(c)
+	}
+	operator, ok := resolveOperatorParam(c, input.NIK)
+	if !ok {
+		return
 	}
+	input.NIK = operator.NIK
+	input.NamaOperator = operator.Nama
 
 	// Simpan ke Database
 	if err := database.DB.Create(&input).Error; err != nil {
@@ -133,9 +168,21 @@ func RecordPressingCycle(c *gin.Context) {
 		return
 	}
 
+	// Mesin "rusak" otomatis membuka tiket perbaikan untuk tim maintenance
+	var ticket *models.RepairTicket
+	if input.StatusMesin == "rusak" {
+		t, _, err := openRepairTicket(c, input.NoMC, "Dilaporkan rusak dari input cycle", input.NIK, input.ID)
+		if err == nil {
+			ticket = &t
+		}
+	}
+
+	publishEvent(realtime.EventCycle, input.NoMC, input)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Cycle recorded successfully",
-		"data":    input,
+		"message":       "Cycle recorded successfully",
+		"data":          input,
+		"repair_ticket": ticket,
 	})
 }
 type PressingDashboardData struct {
@@ -172,12 +219,10 @@ func GetPressingDashboard(c *gin.Context) {
 		TodayTarget:    60,
 	}
 
-	// 2. Data Scans Dummy
-	scans := []PressingScan{
-		{ID: 1, Lot: "LOT-2026-001", TimeStart: "08:00", TimeEnd: "08:45", Status: "Selesai", Pic: "Budi"},
-		{ID: 2, Lot: "LOT-2026-002", TimeStart: "09:00", TimeEnd: "09:45", Status: "Selesai", Pic: "Budi"},
-		{ID: 3, Lot: "LOT-2026-003", TimeStart: "10:00", TimeEnd: "-", Status: "Proses", Pic: "Budi"},
-	}
+	// 2. Data Scans: pasangan START/STOP dari scan lot hari ini
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	scans := derivePressingScans(today, today.AddDate(0, 0, 1), c.Query("no_mc"))
 
 	// 3. Data LWP (Ambil dari tabel per_cycles yang baru kita buat)
 	var cycles []models.PerCycle
@@ -195,6 +240,7 @@ func GetPressingDashboard(c *gin.Context) {
 type ScanMachineInput struct {
 	MachineCode string `json:"machineCode" binding:"required"`
 	Timestamp   string `json:"timestamp"`
+	DeviceID    string `json:"deviceId"`
 }
 
 // POST: /api/scan-machine
@@ -205,11 +251,26 @@ func ScanMachine(c *gin.Context) {
 		return
 	}
 
-	// Di sini kamu bisa cek ke DB apakah kode mesin valid
-	// Untuk sekarang kita anggap semua kode yang diawali "PRESS" atau "MESIN" valid
-	// Simpan log atau update status mesin di DB jika perlu
+	scannedAt, err := scanTime(input.Timestamp)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validasi ke registry mesin & catat sebagai scan event
+	scan, err := recordScan(c, ScanInput{
+		Type:      "MACHINE",
+		Code:      input.MachineCode,
+		DeviceID:  input.DeviceID,
+		Timestamp: input.Timestamp,
+	}, scannedAt)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "machine": input.MachineCode})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"scan_id": scan.ID,
 		"message": "Mesin berhasil divalidasi",
 		"machine": input.MachineCode,
 	})
@@ -228,6 +289,22 @@ type LWPInput struct {
 	JamSelesai string `json:"jamSelesai"`
 	HasilOk    int    `json:"hasilOk"`
 	Ng         int    `json:"ng"`
+
+	// Item code (opsional); jika kosong dicari dari master mold / v_stdlot berdasarkan kodePart (mold)
+	ItemCode string `json:"itemCode"`
+
+	// Qty NG per jenis reject (kode dari katalog reject proses mesin)
+	KlasifikasiReject []LWPRejectInput `json:"klasifikasiReject"`
+
+	// Nomor lot dari label yang sudah dicetak (opsional, jika kosong dibuatkan server)
+	NoLot string `json:"noLot"`
+
+	// Traceability (opsional): lot cutting yang dipakai & batch material
+	LotInduk      []string `json:"lotInduk"`
+	BatchMaterial string   `json:"batchMaterial"`
+
+	// true = simpan sebagai draft dulu, belum dikirim ke leader untuk approval
+	Draft bool `json:"draft"`
 }
 
 // POST: /api/lwp (Buat Record Baru)
@@ -258,29 +335,118 @@ func CreateLWP(c *gin.Context) {
         }
     }
 
+	// NIK / nama dari form diseragamkan ke NIK tm_employ
+	operator, ok := resolveOperatorParam(c, input.Nik)
+	if !ok {
+		return
+	}
+	input.Nik = operator.NIK
+
 	// Simpan ke database (di sini kita pakai model PerCycle sebagai contoh sederhana)
 	// Idealnya ada tabel LWP khusus, tapi kita gunakan PerCycle biar cepat integrasi
+	// Nomor lot dibuat server (atomic per mesin/hari), kecuali operator scan label yang sudah dipesan
+	noLot := input.NoLot
+	if noLot == "" {
+		reserved, err := database.NextLotNumbers("PRS", input.NoMesin, 1, 0, input.Nik)
+		if errors.Is(err, database.ErrLotSource) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat nomor lot: " + err.Error()})
+			return
+		}
+		noLot = reserved[0].LotNo
+	}
+
 	cycle := models.PerCycle{
 		NoMC:         input.NoMesin,
 		Item:         input.PartName,
-		NoLot:        "NEW-LOT-" + time.Now().Format("150405"), // Generate NoLot unik
+		NoLot:        noLot,
 		StatusMesin:  "produksi",
-		NamaOperator: input.Nik,
+		NIK:          operator.NIK,
+		NamaOperator: operator.Nama,
+	}
+
+	// Catat lot pressing ke genealogy sekaligus, agar bisa di-trace ke lot cutting asalnya
+	parents := []LotParentInput{}
+	for _, lotNo := range input.LotInduk {
+		parents = append(parents, LotParentInput{LotNo: lotNo})
+	}
+	if input.ItemCode == "" {
+		input.ItemCode = itemCodeForMold(input.KodePart)
+	}
+	now := time.Now()
+	lot := models.Lot{
+		LotNo:         cycle.NoLot,
+		Proses:        "PRS",
+		ItemCode:      input.ItemCode,
+		MoldCode:      input.KodePart,
+		MaterialBatch: input.BatchMaterial,
+		NoMC:          input.NoMesin,
+		OperatorNIK:   input.Nik,
+		Qty:           input.HasilOk + input.Ng,
+		FinishedAt:    &now,
+	}
+
+	// Laporan LWP untuk approval leader (DRAFT atau langsung SUBMITTED)
+	report := newLWPReport(input, noLot)
+	rejects, err := validateLWPQuantities(report, &input.KlasifikasiReject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := database.DB.Create(&cycle).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan LWP"})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := database.UseLotNumber(tx, cycle.NoLot); err != nil {
+			return err
+		}
+		if err := tx.Create(&cycle).Error; err != nil {
+			return fmt.Errorf("Gagal menyimpan LWP")
+		}
+		report.CycleID = cycle.ID
+		if err := tx.Create(&report).Error; err != nil {
+			return fmt.Errorf("Gagal menyimpan laporan LWP")
+		}
+		if err := saveLWPDefects(tx, report.ID, rejects); err != nil {
+			return fmt.Errorf("Gagal menyimpan klasifikasi reject")
+		}
+		return registerLot(tx, &lot, parents)
+	})
+	var parentErr parentLotError
+	if errors.As(err, &parentErr) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "LWP Created", "data": cycle})
+	publishEvent(realtime.EventLWPCreated, cycle.NoMC, gin.H{"lwp": cycle, "ok": input.HasilOk, "ng": input.Ng})
+	publishRejectSpike(cycle.NoMC, cycle.NoLot, input.HasilOk, input.Ng)
+	if report.Status == "SUBMITTED" {
+		notifyLWPSubmitted(report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "LWP Created", "data": cycle, "report": report, "rejects": rejects})
 }
 
-// GET: /api/pressing/weekly-stats
+// GET: /api/pressing/weekly-stats?nik= (parameter lama ?nama= masih diterima)
 func GetPressingWeeklyStats(c *gin.Context) {
-	namaOperator := c.Query("nama")
-	if namaOperator == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter 'nama' operator wajib diisi"})
+	key := c.Query("nik")
+	if key == "" {
+		key = c.Query("nama")
+	}
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter 'nik' operator wajib diisi"})
+		return
+	}
+	if !isDBConnected(c) {
+		return
+	}
+	operator, ok := resolveOperatorParam(c, key)
+	if !ok {
 		return
 	}
 
@@ -307,13 +473,13 @@ func GetPressingWeeklyStats(c *gin.Context) {
 			COALESCE(SUM(OK), 0) as ok,
 			COALESCE(SUM(NG), 0) as ng
 		FROM vtrx_lwp_prs
-		WHERE nama = ?
+		WHERE NPK IN ?
 			AND DATE(CONCAT(thn, '-', LPAD(bln, 2, '0'), '-', LPAD(tgl, 2, '0'))) BETWEEN ? AND ?
 		GROUP BY DATE(CONCAT(thn, '-', LPAD(bln, 2, '0'), '-', LPAD(tgl, 2, '0')))
 		ORDER BY tanggal ASC
 	`
 
-	rows, err := database.MySQL.Raw(query, namaOperator, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Rows()
+	rows, err := database.MySQL.Raw(query, operator.NPKs, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Rows()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal query data mingguan: " + err.Error()})
 		return
@@ -374,6 +540,7 @@ func GetPressingWeeklyStats(c *gin.Context) {
 	todayStats, _ := dataMap[today]
 
 	c.JSON(http.StatusOK, gin.H{
+		"operator":   operator,
 		"weeklyData": weeklyData,
 		"summary": gin.H{
 			"todayCompleted": todayStats.OK,
@@ -383,14 +550,109 @@ func GetPressingWeeklyStats(c *gin.Context) {
 	})
 }
 
-// GetPressingLWPData - Ambil data LWP dari database untuk operator tertentu
+// Satu baris LWP pressing dari vtrx_lwp_prs (join v_stdlot)
+type PressingLWPRecord struct {
+	NoMC              string    `json:"noMesin"`
+	Tanggal           string    `json:"tanggal"`
+	Shift             string    `json:"shift"`
+	Nama              string    `json:"nik"`
+	MoldCode          string    `json:"kodePart"`
+	ItemCode          string    `json:"itemCode"`
+	ItemName          string    `json:"partName"`
+	MoldName          string    `json:"moldName"`
+	LotNo             string    `json:"noLot"`
+	JamMulai          string    `json:"jamMulai"`
+	JamSelesai        string    `json:"jamSelesai"`
+	OK                int       `json:"hasilOk"`
+	NG                int       `json:"ng"`
+	Total             int       `json:"total"`
+	DefectQtys        string    `json:"-"` // Qty reject berurutan sesuai katalog (lihat defectQtysSelect)
+	Rejects           []rejectQty `gorm:"-" json:"rejects"`
+	ReworkOK          int       `gorm:"-" json:"reworkOk"`  // Dari disposisi REWORK (lihat fillReworkOK)
+	OKEfektif         int       `gorm:"-" json:"okEfektif"` // OK + rework OK
+	Jam               float64   `json:"jam"`
+}
+
+// Jenis reject & qty satu record (urutan sesuai katalog reject)
+type rejectQty struct {
+	Kode     string `json:"kode"`
+	Nama     string `json:"jenis"`
+	Severity string `json:"severity"`
+	Qty      int    `json:"qty"`
+}
+
+// Helper: Semua jenis reject satu record (termasuk yang qty 0)
+func (record PressingLWPRecord) rejectQtys() []rejectQty {
+	return record.Rejects
+}
+
+// LWP yang dikelompokkan per mesin & shift
+type LWPMachineGroup struct {
+	NoMesin   string                   `json:"noMesin"`
+	Tanggal   string                   `json:"tanggal"`
+	Shift     string                   `json:"shift"`
+	NIK       string                   `json:"nik"`
+	PartName  string                   `json:"partName"`
+	KodePart  string                   `json:"kodePart"`
+	ItemCode  string                   `json:"itemCode"`
+	MoldName  string                   `json:"moldName"`
+	Details   []map[string]interface{} `json:"details"`
+}
+
+// Query LWP per operator (semua NPK milik operator) per tanggal.
+// %s pertama = kolom reject dari katalog (defectQtysSelect), kedua = sumber data proses (process.SourceWith).
+const lwpRecordQuery = `
+		SELECT 
+			v.noMC,
+			DATE_FORMAT(v.tanggal, '%%Y-%%m-%%d') as tanggal,
+			COALESCE(v.shift, '') as shift,
+			COALESCE(v.nama, '') as nama,
+			v.moldCode as mold_code,
+			COALESCE(s.itemCode, v.itemCode, '-') as itemCode,
+			COALESCE(s.itemName, v.moldCode) as itemName,
+			COALESCE(s.moldName, '') as moldName,
+			v.lotNo,
+			COALESCE(TIME_FORMAT(v.MULAI, '%%H:%%i'), '00:00') as jamMulai,
+			COALESCE(TIME_FORMAT(v.SELESAI, '%%H:%%i'), '00:00') as jamSelesai,
+			COALESCE(v.OK, 0) as OK,
+			COALESCE(v.NG, 0) as NG,
+			COALESCE(v.Total, 0) as Total,
+			%s AS defect_qtys,
+			COALESCE(v.jam, TIME_TO_SEC(TIMEDIFF(v.SELESAI, v.MULAI)) / 3600.0, 0) as jam
+		FROM %s v
+		LEFT JOIN (
+			SELECT itemCode, MAX(itemName) AS itemName, MAX(moldName) AS moldName
+			FROM v_stdlot
+			GROUP BY itemCode
+		) s ON v.itemCode = s.itemCode COLLATE utf8mb4_unicode_ci
+		WHERE v.NPK IN ?
+			AND v.tanggal = ?
+		ORDER BY v.MULAI DESC
+`
+
+// Helper: Query LWP satu proses dengan kolom reject sesuai katalog
+func lwpRecordSQL(def process.Definition, defects []models.DefectType) string {
+	return fmt.Sprintf(lwpRecordQuery, defectQtysSelect("v", defects), def.SourceWith(defectColumns(defects)...))
+}
+
+// Helper: Proses dari ?proses= (default PRS) untuk endpoint LWP
+func lwpProcessParam(c *gin.Context) (process.Definition, bool) {
+	code := c.DefaultQuery("proses", "PRS")
+	def, ok := process.Get(code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Proses " + code + " tidak terdaftar"})
+	}
+	return def, ok
+}
+
+// GetPressingLWPData - Ambil data LWP dari database untuk operator tertentu (?proses=, default PRS)
 func GetPressingLWPData(c *gin.Context) {
-	namaOperator := c.Query("nik")
+	nik := c.Query("nik")
 	tanggal := c.Query("tanggal")
 	
-	fmt.Printf("[LWP DATA] Request received - NIK: %s, Tanggal: %s\n", namaOperator, tanggal)
+	fmt.Printf("[LWP DATA] Request received - NIK: %s, Tanggal: %s\n", nik, tanggal)
 	
-	if namaOperator == "" {
+	if nik == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter 'nik' wajib diisi"})
 		return
 	}
@@ -399,18 +661,16 @@ func GetPressingLWPData(c *gin.Context) {
 		tanggal = time.Now().Format("2006-01-02")
 	}
 
-	parsedDate, err := time.Parse("2006-01-02", tanggal)
-	if err != nil {
+	if _, err := time.Parse("2006-01-02", tanggal); err != nil {
 		fmt.Printf("[LWP DATA ERROR] Parse date failed: %v\n", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah (gunakan YYYY-MM-DD)"})
 		return
 	}
 
-	thn := parsedDate.Year()
-	bln := int(parsedDate.Month())
-	tgl := parsedDate.Day()
-
-	fmt.Printf("[LWP DATA] Parsed date - Tahun: %d, Bulan: %d, Tanggal: %d\n", thn, bln, tgl)
+	def, ok := lwpProcessParam(c)
+	if !ok {
+		return
+	}
 
 	if database.MySQL == nil {
 		fmt.Printf("[LWP DATA ERROR] MySQL connection is nil\n")
@@ -421,114 +681,26 @@ func GetPressingLWPData(c *gin.Context) {
 		return
 	}
 
-	type LWPRecord struct {
-		NoMC              string    `json:"noMesin"`
-		Tanggal           string    `json:"tanggal"`
-		Shift             string    `json:"shift"`
-		Nama              string    `json:"nik"`
-		MoldCode          string    `json:"kodePart"`
-		ItemCode          string    `json:"itemCode"`
-		ItemName          string    `json:"partName"`
-		MoldName          string    `json:"moldName"`
-		LotNo             string    `json:"noLot"`
-		JamMulai          string    `json:"jamMulai"`
-		JamSelesai        string    `json:"jamSelesai"`
-		OK                int       `json:"hasilOk"`
-		NG                int       `json:"ng"`
-		Total             int       `json:"total"`
-		Bintik            int       `json:"bintik"`
-		TNgisi            int       `json:"tNgisi"`
-		Lengket           int       `json:"lengket"`
-		Deform            int       `json:"deform"`
-		Mentah            int       `json:"mentah"`
-		Retak             int       `json:"retak"`
-		Robek             int       `json:"robek"`
-		KBody             int       `json:"kBody"`
-		Kotor             int       `json:"kotor"`
-		CCavity           int       `json:"cCavity"`
-		Karat             int       `json:"karat"`
-		CMetal            int       `json:"cMetal"`
-		Angin             int       `json:"angin"`
-		Runner            int       `json:"runner"`
-		Bonding           int       `json:"bonding"`
-		Dimensi           int       `json:"dimensi"`
-		Hardness          int       `json:"hardness"`
-		Bloming           int       `json:"bloming"`
-		SalahSlit         int       `json:"salahSlit"`
-		Champer           int       `json:"champer"`
-		MtlKelihatan      int       `json:"mtlKelihatan"`
-		Burry             int       `json:"burry"`
-		Miring            int       `json:"miring"`
-		Mampet            int       `json:"mampet"`
-		Lain2             int       `json:"lain2"`
-		Jam               float64   `json:"jam"`
-	}
-
-	var records []LWPRecord
+	// NIK -> semua NPK operator (termasuk NPK lama dari alias)
+	operator, ok := resolveOperatorParam(c, nik)
+	if !ok {
+		return
+	}
 
-	query := `
-		SELECT 
-			v.noMC,
-			CONCAT(v.thn, '-', LPAD(v.bln, 2, '0'), '-', LPAD(v.tgl, 2, '0')) as tanggal,
-			COALESCE(v.shift, '') as shift,
-			COALESCE(v.nama, '') as nama,
-			v.moldcode,
-			COALESCE(s.itemCode, '-') as itemCode,
-			COALESCE(s.itemName, v.moldcode) as itemName,
-			COALESCE(s.moldName, '') as moldName,
-			v.lotNo,
-			COALESCE(TIME_FORMAT(v.MULAI, '%H:%i'), '00:00') as jamMulai,
-			COALESCE(TIME_FORMAT(v.SELESAI, '%H:%i'), '00:00') as jamSelesai,
-			COALESCE(v.OK, 0) as OK,
-			COALESCE(v.NG, 0) as NG,
-			COALESCE(v.Total, 0) as Total,
-			COALESCE(v.Bintik, 0) as bintik,
-			COALESCE(v.TNgisi, 0) as tNgisi,
-			COALESCE(v.Lengket, 0) as lengket,
-			COALESCE(v.Deform, 0) as deform,
-			COALESCE(v.Mentah, 0) as mentah,
-			COALESCE(v.Retak, 0) as retak,
-			COALESCE(v.Robek, 0) as robek,
-			COALESCE(v.KBody, 0) as kBody,
-			COALESCE(v.Kotor, 0) as kotor,
-			COALESCE(v.CCavity, 0) as cCavity,
-			COALESCE(v.Karat, 0) as karat,
-			COALESCE(v.CMetal, 0) as cMetal,
-			COALESCE(v.Angin, 0) as angin,
-			COALESCE(v.Runner, 0) as runner,
-			COALESCE(v.Bonding, 0) as bonding,
-			COALESCE(v.Dimensi, 0) as dimensi,
-			COALESCE(v.Hardness, 0) as hardness,
-			COALESCE(v.Bloming, 0) as bloming,
-			COALESCE(v.SalahSlit, 0) as salahSlit,
-			COALESCE(v.Champer, 0) as champer,
-			COALESCE(v.MtlKelihatan, 0) as mtlKelihatan,
-			COALESCE(v.Burry, 0) as burry,
-			COALESCE(v.Miring, 0) as miring,
-			COALESCE(v.Mampet, 0) as mampet,
-			COALESCE(v.lain2, 0) as lain2,
-			COALESCE(v.jam, 0) as jam
-		FROM vtrx_lwp_prs v
-		LEFT JOIN v_stdlot s ON v.itemCode = s.itemCode
-		WHERE v.NPK = ? 
-			AND v.thn = ? 
-			AND v.bln = ? 
-			AND v.tgl = ?
-		ORDER BY v.MULAI DESC
-	`
+	var records []PressingLWPRecord
+	defects := database.DefectTypes(def.Code, false)
 
-	fmt.Printf("[LWP DATA] Executing query with params - Nama: %s, Thn: %d, Bln: %d, Tgl: %d\n", 
-		namaOperator, thn, bln, tgl)
+	fmt.Printf("[LWP DATA] Executing query with params - Proses: %s, NIK: %s, NPK: %v, Tanggal: %s\n", 
+		def.Code, operator.NIK, operator.NPKs, tanggal)
 
-	if err := database.MySQL.Raw(query, namaOperator, thn, bln, tgl).Scan(&records).Error; err != nil {
+	if err := database.MySQL.Raw(lwpRecordSQL(def, defects), operator.NPKs, tanggal).Scan(&records).Error; err != nil {
 		fmt.Printf("[LWP DATA ERROR] Query failed: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal mengambil data LWP: " + err.Error(),
 			"query_params": gin.H{
-				"nama": namaOperator,
-				"thn": thn,
-				"bln": bln,
-				"tgl": tgl,
+				"proses":  def.Code,
+				"nik":     operator.NIK,
+				"tanggal": tanggal,
 			},
 		})
 		return
@@ -537,8 +709,9 @@ func GetPressingLWPData(c *gin.Context) {
 	fmt.Printf("[LWP DATA] Query successful - Records found: %d\n", len(records))
 
 	if len(records) == 0 {
-		fmt.Printf("[LWP DATA] No records found for Nama: %s on date: %d-%d-%d\n", namaOperator, thn, bln, tgl)
+		fmt.Printf("[LWP DATA] No records found for NIK: %s on date: %s\n", operator.NIK, tanggal)
 		c.JSON(http.StatusOK, gin.H{
+			"operator":   operator,
 			"lwpRecords": []interface{}{},
 			"total":      0,
 			"message":    "Tidak ada data LWP untuk hari ini",
@@ -546,26 +719,54 @@ func GetPressingLWPData(c *gin.Context) {
 		return
 	}
 
+	fillRejects(records, defects)
+	fillReworkOK(records)
+
 	// Group data berdasarkan mesin
-	type MachineGroup struct {
-		NoMesin   string                   `json:"noMesin"`
-		Tanggal   string                   `json:"tanggal"`
-		Shift     string                   `json:"shift"`
-		NIK       string                   `json:"nik"`
-		PartName  string                   `json:"partName"`
-		KodePart  string                   `json:"kodePart"`
-		ItemCode  string                   `json:"itemCode"`
-		MoldName  string                   `json:"moldName"`
-		Details   []map[string]interface{} `json:"details"`
+	result := groupPressingLWP(records)
+
+	fmt.Printf("[LWP DATA] Response prepared - Machine groups: %d\n", len(result))
+
+	c.JSON(http.StatusOK, gin.H{
+		"operator":   operator,
+		"lwpRecords": result,
+		"total":      len(result),
+	})
+}
+
+// Helper: Isi rework OK & OK efektif setiap record dari laporan LWP (kunci mesin + tanggal + jam mulai).
+// Rework satu kunci hanya ditambahkan ke record pertama agar baris ERP dengan kunci sama tidak dihitung dua kali.
+func fillReworkOK(records []PressingLWPRecord) {
+	if len(records) == 0 {
+		return
+	}
+	from, to := records[0].Tanggal, records[0].Tanggal
+	for _, r := range records {
+		if r.Tanggal < from {
+			from = r.Tanggal
+		}
+		if r.Tanggal > to {
+			to = r.Tanggal
+		}
 	}
+	rework := reworkOKByKey(from, to)
+	for i := range records {
+		key := lwpKey{records[i].NoMC, records[i].Tanggal, lwpJamKey(records[i].JamMulai)}
+		records[i].ReworkOK = rework[key]
+		delete(rework, key)
+		records[i].OKEfektif = records[i].OK + records[i].ReworkOK
+	}
+}
 
-	machineMap := make(map[string]*MachineGroup)
+// Helper: Kelompokkan record LWP per mesin & shift (dipakai endpoint LWP dan export)
+func groupPressingLWP(records []PressingLWPRecord) []LWPMachineGroup {
+	machineMap := make(map[string]*LWPMachineGroup)
 
 	for _, record := range records {
 		key := record.NoMC + "_" + record.Shift
 
 		if _, exists := machineMap[key]; !exists {
-			machineMap[key] = &MachineGroup{
+			machineMap[key] = &LWPMachineGroup{
 				NoMesin:  record.NoMC,
 				Tanggal:  record.Tanggal,
 				Shift:    record.Shift,
@@ -581,42 +782,13 @@ func GetPressingLWPData(c *gin.Context) {
 // Build klasifikasi reject - hanya jenis dan qty yang ada saja
 klasifikasiReject := []map[string]interface{}{}
 
-rejectTypes := []struct {
-    Nama string
-    Qty  int
-}{
-    {"Bintik", record.Bintik},
-    {"Tidak Ngisi", record.TNgisi},
-    {"Lengket", record.Lengket},
-    {"Deform", record.Deform},
-    {"Mentah", record.Mentah},
-    {"Retak", record.Retak},
-    {"Robek", record.Robek},
-    {"K-Body", record.KBody},
-    {"Kotor", record.Kotor},
-    {"C-Cavity", record.CCavity},
-    {"Karat", record.Karat},
-    {"C-Metal", record.CMetal},
-    {"Angin", record.Angin},
-    {"Runner", record.Runner},
-    {"Bonding", record.Bonding},
-    {"Dimensi", record.Dimensi},
-    {"Hardness", record.Hardness},
-    {"Bloming", record.Bloming},
-    {"Salah Slit", record.SalahSlit},
-    {"Champer", record.Champer},
-    {"Material Kelihatan", record.MtlKelihatan},
-    {"Burry", record.Burry},
-    {"Miring", record.Miring},
-    {"Mampet", record.Mampet},
-    {"Lain-lain", record.Lain2},
-}
-
-for _, r := range rejectTypes {
+for _, r := range record.rejectQtys() {
     if r.Qty > 0 {
         klasifikasiReject = append(klasifikasiReject, map[string]interface{}{
-            "jenis": r.Nama,
-            "qty":   r.Qty,
+            "kode":     r.Kode,
+            "jenis":    r.Nama,
+            "severity": r.Severity,
+            "qty":      r.Qty,
         })
     }
 }
@@ -626,6 +798,8 @@ detail := map[string]interface{}{
     "jamMulai":          record.JamMulai,
     "jamSelesai":        record.JamSelesai,	
     "hasilOk":           record.OK,
+    "reworkOk":          record.ReworkOK,
+    "okEfektif":         record.OKEfektif,
     "ng":                record.NG,
     "total":             record.Total,
     "jam":               record.Jam,
@@ -636,15 +810,10 @@ detail := map[string]interface{}{
 	}
 
 	// Convert map to array
-	result := []MachineGroup{}
+	result := []LWPMachineGroup{}
 	for _, group := range machineMap {
 		result = append(result, *group)
 	}
 
-	fmt.Printf("[LWP DATA] Response prepared - Machine groups: %d\n", len(result))
-
-	c.JSON(http.StatusOK, gin.H{
-		"lwpRecords": result,
-		"total":      len(result),
-	})
+	return result
 }
\ No newline at end of file
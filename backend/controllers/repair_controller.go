@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role yang diberi tahu saat ada tiket perbaikan baru
+var repairNotifyRoles = []string{"MAINTENANCE", "LEADER"}
+
+// Helper: Buka tiket perbaikan (dipakai endpoint & RecordPressingCycle). Tidak dobel jika masih ada tiket terbuka.
+func openRepairTicket(c *gin.Context, noMC, symptom, reportedBy string, cycleID uint) (models.RepairTicket, bool, error) {
+	var ticket models.RepairTicket
+	err := database.DB.Where("no_mc = ? AND status <> ?", noMC, "CLOSED").First(&ticket).Error
+	if err == nil {
+		return ticket, false, nil
+	}
+
+	ticket = models.RepairTicket{
+		NoMC:       noMC,
+		Status:     "OPEN",
+		Symptom:    symptom,
+		ReportedBy: reportedBy,
+		ReportedAt: time.Now(),
+		CycleID:    cycleID,
+	}
+	if err := database.DB.Create(&ticket).Error; err != nil {
+		return ticket, false, err
+	}
+
+	setMachineStatus(noMC, "DOWN")
+	recordAudit(c, "OPEN_REPAIR_TICKET", fmt.Sprintf("#%d MC %s: %s", ticket.ID, noMC, symptom))
+	notify.NotifyRoles("repair.opened", repairNotifyRoles, map[string]interface{}{
+		"id":          ticket.ID,
+		"no_mc":       ticket.NoMC,
+		"symptom":     ticket.Symptom,
+		"reported_by": ticket.ReportedBy,
+		"reported_at": ticket.ReportedAt.Format("2006-01-02 15:04"),
+	})
+	return ticket, true, nil
+}
+
+// POST: /api/repairs - Operator lapor mesin rusak
+func CreateRepairTicket(c *gin.Context) {
+	var input struct {
+		NoMC    string `json:"no_mc" binding:"required"`
+		Symptom string `json:"symptom" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	ticket, created, err := openRepairTicket(c, input.NoMC, input.Symptom, currentNIK(c), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tiket perbaikan"})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusConflict, gin.H{"error": "Masih ada tiket terbuka untuk mesin " + input.NoMC, "data": ticket})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Tiket perbaikan dibuat", "data": ticket})
+}
+
+// GET: /api/repairs?status=OPEN&no_mc=04A
+func GetRepairTickets(c *gin.Context) {
+	query := database.DB.Order("reported_at desc")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+
+	var tickets []models.RepairTicket
+	query.Limit(200).Find(&tickets)
+	c.JSON(http.StatusOK, gin.H{"total": len(tickets), "data": tickets})
+}
+
+// POST: /api/repairs/:id/acknowledge - Maintenance menerima tiket & mulai perbaikan
+func AcknowledgeRepairTicket(c *gin.Context) {
+	var ticket models.RepairTicket
+	if err := database.DB.First(&ticket, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tiket tidak ditemukan"})
+		return
+	}
+	if ticket.Status != "OPEN" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tiket sudah " + ticket.Status})
+		return
+	}
+
+	now := time.Now()
+	ticket.Status = "ACKNOWLEDGED"
+	ticket.AcknowledgedBy = currentNIK(c)
+	ticket.AcknowledgedAt = &now
+	database.DB.Save(&ticket)
+
+	setMachineStatus(ticket.NoMC, "REPAIR")
+	recordAudit(c, "ACK_REPAIR_TICKET", fmt.Sprintf("#%d MC %s", ticket.ID, ticket.NoMC))
+	c.JSON(http.StatusOK, gin.H{"message": "Tiket diterima maintenance", "data": ticket})
+}
+
+// POST: /api/repairs/:id/close - Catat penyebab & tindakan, mesin kembali siap
+func CloseRepairTicket(c *gin.Context) {
+	var ticket models.RepairTicket
+	if err := database.DB.First(&ticket, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tiket tidak ditemukan"})
+		return
+	}
+	if ticket.Status == "CLOSED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tiket sudah ditutup"})
+		return
+	}
+
+	var input struct {
+		Cause  string `json:"cause" binding:"required"`
+		Action string `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cause dan action wajib diisi"})
+		return
+	}
+
+	now := time.Now()
+	if ticket.AcknowledgedAt == nil {
+		ticket.AcknowledgedAt = &now
+		ticket.AcknowledgedBy = currentNIK(c)
+	}
+	ticket.Status = "CLOSED"
+	ticket.Cause = input.Cause
+	ticket.Action = input.Action
+	ticket.ClosedBy = currentNIK(c)
+	ticket.ClosedAt = &now
+	if err := database.DB.Save(&ticket).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menutup tiket"})
+		return
+	}
+
+	setMachineStatus(ticket.NoMC, machineReadyStatus(ticket.NoMC))
+	recordAudit(c, "CLOSE_REPAIR_TICKET",
+		fmt.Sprintf("#%d MC %s: %s", ticket.ID, ticket.NoMC, input.Cause))
+	c.JSON(http.StatusOK, gin.H{"message": "Tiket ditutup, mesin siap digunakan", "data": ticket})
+}
+
+// Statistik keandalan mesin
+type ReliabilityStats struct {
+	NoMC          string  `json:"no_mc"`
+	Failures      int     `json:"failures"`
+	DowntimeHours float64 `json:"downtime_hours"`
+	Open          int     `json:"open"`                 // Kerusakan yang belum CLOSED (downtime dihitung sampai sekarang)
+	MTTRHours     float64 `json:"mttr_hours"`           // Downtime / jumlah kerusakan
+	MTBFHours     float64 `json:"mtbf_hours"`           // (Jam periode - downtime) / jumlah kerusakan
+	AvgResponse   float64 `json:"avg_response_minutes"` // Rata-rata lapor -> acknowledge
+
+	acknowledged int
+}
+
+// Helper: Jam downtime satu tiket yang jatuh di rentang [from, to). Tiket yang belum close dihitung sampai sekarang.
+func ticketDowntime(t models.RepairTicket, from, to time.Time) float64 {
+	start, end := t.ReportedAt, to
+	if start.Before(from) {
+		start = from
+	}
+	if t.ClosedAt != nil && t.ClosedAt.Before(to) {
+		end = *t.ClosedAt
+	} else if t.ClosedAt == nil && time.Now().Before(to) {
+		end = time.Now()
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// GET: /api/repairs/report?from=&to= - MTTR & MTBF per mesin
+func GetRepairReport(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	periodHours := to.Sub(from).Hours()
+
+	// Semua tiket yang downtime-nya overlap periode (termasuk yang masih terbuka / dilaporkan sebelum periode);
+	// kerusakan dihitung dari tiket yang dilaporkan di dalam periode, downtime dipotong ke [from, to)
+	var tickets []models.RepairTicket
+	query := database.DB.Where("reported_at < ? AND (closed_at IS NULL OR closed_at >= ?)", to, from)
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	query.Find(&tickets)
+
+	stats := make(map[string]*ReliabilityStats)
+	for _, t := range tickets {
+		s, ok := stats[t.NoMC]
+		if !ok {
+			s = &ReliabilityStats{NoMC: t.NoMC}
+			stats[t.NoMC] = s
+		}
+		s.DowntimeHours += ticketDowntime(t, from, to)
+		if t.ReportedAt.Before(from) {
+			continue
+		}
+		s.Failures++
+		if t.Status != "CLOSED" {
+			s.Open++
+		}
+		if t.AcknowledgedAt != nil {
+			s.AvgResponse += t.AcknowledgedAt.Sub(t.ReportedAt).Minutes()
+			s.acknowledged++
+		}
+	}
+
+	result := []ReliabilityStats{}
+	for _, s := range stats {
+		if s.Failures > 0 {
+			s.MTTRHours = s.DowntimeHours / float64(s.Failures)
+			s.MTBFHours = (periodHours - s.DowntimeHours) / float64(s.Failures)
+		}
+		if s.acknowledged > 0 {
+			s.AvgResponse = s.AvgResponse / float64(s.acknowledged)
+		}
+		result = append(result, *s)
+	}
+	// MTBF terendah dulu; mesin tanpa kerusakan baru (hanya downtime sisa periode lalu) di akhir
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Failures == 0) != (result[j].Failures == 0) {
+			return result[j].Failures == 0
+		}
+		return result[i].MTBFHours < result[j].MTBFHours
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         from.Format("2006-01-02"),
+		"to":           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"period_hours": periodHours,
+		"data":         result,
+	})
+}
@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/notify"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/robfig/cron/v3"
+)
+
+// Mencegah jadwal yang sama dijalankan dua kali (ticker + trigger manual)
+var reportRunMu sync.Mutex
+
+// Jenis laporan yang didukung
+var reportKinds = map[string]bool{"DAILY_SUMMARY": true}
+
+// Helper: Folder penyimpanan file laporan (env REPORT_DIR, default reports)
+func reportDir() string {
+	if v := os.Getenv("REPORT_DIR"); v != "" {
+		return v
+	}
+	return "reports"
+}
+
+// Helper: URL dasar untuk link download di notifikasi (env PUBLIC_BASE_URL)
+func publicBaseURL() string {
+	if v := os.Getenv("PUBLIC_BASE_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// Helper: Masa berlaku link download di email (env REPORT_LINK_TTL_HOURS, default 72 jam)
+func reportLinkTTL() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("REPORT_LINK_TTL_HOURS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return 72 * time.Hour
+}
+
+// Helper: Token download bertanda tangan untuk satu file laporan (tanpa role, jadi tidak bisa dipakai sebagai login)
+func reportDownloadToken(runID uint, expires time.Time) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": "report_download",
+		"run_id":  runID,
+		"exp":     expires.Unix(),
+	}).SignedString(jwtKey)
+}
+
+// Helper: Link download laporan yang bisa dibuka langsung dari email
+func reportDownloadURL(runID uint) (string, error) {
+	token, err := reportDownloadToken(runID, time.Now().Add(reportLinkTTL()))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/report-files/%d?token=%s", publicBaseURL(), runID, token), nil
+}
+
+// Helper: Hitung jadwal berikutnya dari ekspresi cron
+func nextCronRun(spec string, after time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cron tidak valid: %w", err)
+	}
+	return sched.Next(after), nil
+}
+
+// Helper: Tabel downtime mesin dari tiket perbaikan yang overlap dengan hari laporan
+func downtimeTable(day time.Time) exportTable {
+	end := day.AddDate(0, 0, 1)
+	table := exportTable{Sheet: "Downtime", Title: "Downtime Mesin", Columns: []exportColumn{
+		{Header: "Mesin", Width: 10}, {Header: "Kerusakan", Width: 10, Sum: true},
+		{Header: "Downtime (jam)", Width: 14, Sum: true}, {Header: "Masih Terbuka", Width: 12, Sum: true},
+	}}
+
+	var tickets []models.RepairTicket
+	database.DB.Where("reported_at < ? AND (closed_at IS NULL OR closed_at >= ?)", end, day).Find(&tickets)
+
+	type downtime struct {
+		count, open int
+		hours       float64
+	}
+	perMachine := map[string]*downtime{}
+	for _, t := range tickets {
+		d, ok := perMachine[t.NoMC]
+		if !ok {
+			d = &downtime{}
+			perMachine[t.NoMC] = d
+		}
+		// Hanya bagian downtime yang jatuh di hari laporan
+		d.count++
+		d.hours += ticketDowntime(t, day, end)
+		if t.Status != "CLOSED" {
+			d.open++
+		}
+	}
+
+	for noMC, d := range perMachine {
+		table.Rows = append(table.Rows, []interface{}{noMC, d.count, d.hours, d.open})
+	}
+	sort.Slice(table.Rows, func(i, j int) bool {
+		return table.Rows[i][2].(float64) > table.Rows[j][2].(float64)
+	})
+	return table
+}
+
+// Helper: Susun laporan ringkasan harian (pencapaian per proses, top 5 NG, downtime, disposisi NG)
+func buildDailySummary(day time.Time) ([]exportTable, string, error) {
+	if database.MySQL == nil {
+		return nil, "", errors.New("Database Statistik (MySQL) tidak terhubung")
+	}
+	tanggal := day.Format("2006-01-02")
+
+	achievement := exportTable{Sheet: "Pencapaian", Title: "Pencapaian per Proses", Columns: chartColumns("Proses")}
+	series, err := managerSeries(tanggal, "")
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal ambil data manager: %w", err)
+	}
+
+	topNG := exportTable{Sheet: "Top 5 NG", Title: "Top 5 Mesin NG", Columns: []exportColumn{
+		{Header: "Mesin", Width: 10}, {Header: "Proses", Width: 10},
+		{Header: "Aktual", Width: 12, Sum: true}, {Header: "NG", Width: 10, Sum: true},
+		{Header: "NG %", Width: 10, IsRatio: true, Num: 3, Den: 2},
+	}}
+
+	lines := []string{}
+	for _, s := range series {
+		achievement.Rows = append(achievement.Rows, []interface{}{s.Label, s.Target, s.Actual, s.ActualNG, nil, nil})
+		lines = append(lines, fmt.Sprintf("%s: %.0f / %.0f (%.1f%%), NG %.0f, OK efektif %.0f (rework %.0f)",
+			s.Label, s.Actual, s.Target, exportRatio(s.Actual, s.Target)*100, s.ActualNG, s.ActualOK, s.ReworkOK))
+
+		machines, err := processSeries(tanggal, s.Label, "")
+		if err != nil {
+			return nil, "", fmt.Errorf("gagal ambil data proses %s: %w", s.Label, err)
+		}
+		for _, m := range machines {
+			topNG.Rows = append(topNG.Rows, []interface{}{m.Label, s.Label, m.Actual, m.ActualNG, nil})
+		}
+	}
+	sort.SliceStable(topNG.Rows, func(i, j int) bool {
+		return topNG.Rows[i][3].(float64) > topNG.Rows[j][3].(float64)
+	})
+	if len(topNG.Rows) > 5 {
+		topNG.Rows = topNG.Rows[:5]
+	}
+
+	downtime := downtimeTable(day)
+	if len(topNG.Rows) > 0 {
+		lines = append(lines, fmt.Sprintf("NG tertinggi: mesin %v (%.0f pcs)", topNG.Rows[0][0], topNG.Rows[0][3]))
+	}
+	lines = append(lines, fmt.Sprintf("Mesin dengan downtime: %d", len(downtime.Rows)))
+
+	dispositions := dispositionSummary(tanggal, day.AddDate(0, 0, 1).Format("2006-01-02"))
+	line := fmt.Sprintf("Disposisi NG: rework %d, scrap %d, use-as-is %d, belum didisposisi %d; OK efektif LWP %d (OK %d + rework %d)",
+		dispositions.Rework, dispositions.Scrap, dispositions.UseAsIs, dispositions.Open,
+		dispositions.OKEfektif, dispositions.OK, dispositions.ReworkOK)
+	if dispositions.ScrapCost > 0 {
+		line += fmt.Sprintf(" (biaya scrap %.0f)", dispositions.ScrapCost)
+	}
+	lines = append(lines, line)
+
+	return []exportTable{achievement, topNG, downtime, dispositionTable(dispositions)}, strings.Join(lines, "\n"), nil
+}
+
+// RunReportSchedule membuat laporan untuk satu jadwal, menyimpan file & mengirim notifikasi
+func RunReportSchedule(schedule models.ReportSchedule, day time.Time, triggeredBy string) models.ReportRun {
+	reportRunMu.Lock()
+	defer reportRunMu.Unlock()
+
+	run := models.ReportRun{
+		ScheduleID:  schedule.ID,
+		ReportDate:  day.Format("2006-01-02"),
+		Status:      "RUNNING",
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	database.DB.Create(&run)
+
+	fail := func(err error) models.ReportRun {
+		now := time.Now()
+		run.Status = "FAILED"
+		run.Error = err.Error()
+		run.FinishedAt = &now
+		database.DB.Save(&run)
+		fmt.Printf("[REPORT ERROR] %s (%s): %v\n", schedule.Name, run.ReportDate, err)
+		return run
+	}
+
+	tables, summary, err := buildDailySummary(day)
+	if err != nil {
+		return fail(err)
+	}
+
+	meta := exportMeta{
+		Company:     companyName(),
+		Title:       schedule.Name,
+		From:        day,
+		To:          day,
+		GeneratedBy: triggeredBy,
+		GeneratedAt: time.Now(),
+	}
+	var data []byte
+	if schedule.Format == "pdf" {
+		data, err = renderExportPDF(meta, tables)
+	} else {
+		schedule.Format = "xlsx"
+		data, err = renderExportXLSX(meta, tables)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := os.MkdirAll(reportDir(), 0o755); err != nil {
+		return fail(err)
+	}
+	run.FileName = fmt.Sprintf("report-%d-%d-%s.%s", schedule.ID, run.ID, day.Format("20060102"), schedule.Format)
+	if err := os.WriteFile(filepath.Join(reportDir(), run.FileName), data, 0o644); err != nil {
+		return fail(err)
+	}
+
+	url, err := reportDownloadURL(run.ID)
+	if err != nil {
+		return fail(err)
+	}
+	notifyData := map[string]interface{}{
+		"name":    schedule.Name,
+		"tanggal": run.ReportDate,
+		"summary": summary,
+		"url":     url,
+	}
+	run.Notified = notify.NotifyRoles("report.ready", schedule.Roles, notifyData) +
+		notify.NotifyUsers("report.ready", schedule.NIKs, notifyData)
+
+	now := time.Now()
+	run.Status = "SUCCESS"
+	run.Summary = summary
+	run.FinishedAt = &now
+	database.DB.Save(&run)
+	return run
+}
+
+// RunDueReports menjalankan semua jadwal aktif yang sudah waktunya (laporan untuk hari kemarin)
+func RunDueReports(now time.Time) int {
+	var schedules []models.ReportSchedule
+	database.DB.Where("aktif = ? AND next_run_at <= ?", true, now).Find(&schedules)
+
+	y, m, d := now.Date()
+	yesterday := time.Date(y, m, d, 0, 0, 0, 0, time.Local).AddDate(0, 0, -1)
+
+	for _, s := range schedules {
+		RunReportSchedule(s, yesterday, "SCHEDULER")
+
+		next, err := nextCronRun(s.Cron, now)
+		updates := map[string]interface{}{"last_run_at": now, "next_run_at": next}
+		if err != nil {
+			updates = map[string]interface{}{"last_run_at": now, "aktif": false}
+		}
+		database.DB.Model(&models.ReportSchedule{}).Where("id = ?", s.ID).Updates(updates)
+	}
+	return len(schedules)
+}
+
+// StartReportScheduler mengecek jadwal laporan secara berkala (dipanggil dari main sebagai goroutine)
+func StartReportScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		RunDueReports(time.Now())
+	}
+}
+
+// Input jadwal laporan
+type ReportScheduleInput struct {
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Cron   string   `json:"cron"`
+	Format string   `json:"format"`
+	Roles  []string `json:"roles"`
+	NIKs   []string `json:"niks"`
+	Aktif  *bool    `json:"aktif"`
+}
+
+// GET: /api/reports/schedules
+func GetReportSchedules(c *gin.Context) {
+	var schedules []models.ReportSchedule
+	database.DB.Order("id asc").Find(&schedules)
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// POST: /api/reports/schedules - Buat jadwal laporan baru
+func CreateReportSchedule(c *gin.Context) {
+	var input ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Name == "" || input.Cron == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name dan cron wajib diisi"})
+		return
+	}
+	if input.Kind == "" {
+		input.Kind = "DAILY_SUMMARY"
+	}
+	if !reportKinds[input.Kind] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis laporan tidak dikenal: " + input.Kind})
+		return
+	}
+	if input.Format != "" && input.Format != "xlsx" && input.Format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format harus xlsx atau pdf"})
+		return
+	}
+	next, err := nextCronRun(input.Cron, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := models.ReportSchedule{
+		Name:      input.Name,
+		Kind:      input.Kind,
+		Cron:      input.Cron,
+		Format:    input.Format,
+		Roles:     input.Roles,
+		NIKs:      input.NIKs,
+		Aktif:     true,
+		CreatedBy: currentNIK(c),
+		NextRunAt: &next,
+	}
+	if schedule.Format == "" {
+		schedule.Format = "xlsx"
+	}
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan jadwal laporan"})
+		return
+	}
+	if input.Aktif != nil && !*input.Aktif {
+		database.DB.Model(&schedule).Update("aktif", false)
+		schedule.Aktif = false
+	}
+
+	recordAudit(c, "CREATE_REPORT_SCHEDULE", fmt.Sprintf("#%d %s (%s)", schedule.ID, schedule.Name, schedule.Cron))
+	c.JSON(http.StatusCreated, gin.H{"message": "Jadwal laporan dibuat", "data": schedule})
+}
+
+// PUT: /api/reports/schedules/:id
+func UpdateReportSchedule(c *gin.Context) {
+	var schedule models.ReportSchedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jadwal laporan tidak ditemukan"})
+		return
+	}
+
+	var input ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != "" {
+		schedule.Name = input.Name
+	}
+	if input.Format == "xlsx" || input.Format == "pdf" {
+		schedule.Format = input.Format
+	}
+	if input.Roles != nil {
+		schedule.Roles = input.Roles
+	}
+	if input.NIKs != nil {
+		schedule.NIKs = input.NIKs
+	}
+	if input.Aktif != nil {
+		schedule.Aktif = *input.Aktif
+	}
+	if input.Cron != "" {
+		schedule.Cron = input.Cron
+	}
+	next, err := nextCronRun(schedule.Cron, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule.NextRunAt = &next
+
+	database.DB.Save(&schedule)
+	recordAudit(c, "UPDATE_REPORT_SCHEDULE", fmt.Sprintf("#%d %s", schedule.ID, schedule.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Jadwal laporan diupdate", "data": schedule})
+}
+
+// POST: /api/reports/schedules/:id/run - Jalankan sekarang ({"tanggal": "2026-02-01"}, default kemarin)
+func RunReportScheduleNow(c *gin.Context) {
+	var schedule models.ReportSchedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jadwal laporan tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Tanggal string `json:"tanggal"`
+	}
+	c.ShouldBindJSON(&input)
+
+	y, m, d := time.Now().Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.Local).AddDate(0, 0, -1)
+	if input.Tanggal != "" {
+		t, err := time.ParseInLocation("2006-01-02", input.Tanggal, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah (gunakan YYYY-MM-DD)"})
+			return
+		}
+		day = t
+	}
+
+	run := RunReportSchedule(schedule, day, currentNIK(c))
+	if run.Status == "FAILED" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Laporan gagal dibuat: " + run.Error, "data": run})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Laporan dibuat", "data": run})
+}
+
+// GET: /api/reports/runs?schedule_id=&status=
+func GetReportRuns(c *gin.Context) {
+	query := database.DB.Order("started_at desc").Limit(100)
+	if id := c.Query("schedule_id"); id != "" {
+		query = query.Where("schedule_id = ?", id)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.ReportRun
+	query.Find(&runs)
+	c.JSON(http.StatusOK, gin.H{"total": len(runs), "data": runs})
+}
+
+// Helper: Kirim file hasil eksekusi laporan
+func serveReportRun(c *gin.Context, id string) {
+	var run models.ReportRun
+	if err := database.DB.First(&run, id).Error; err != nil || run.FileName == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File laporan tidak ditemukan"})
+		return
+	}
+	c.FileAttachment(filepath.Join(reportDir(), run.FileName), run.FileName)
+}
+
+// GET: /api/reports/runs/:id/download
+func DownloadReportRun(c *gin.Context) {
+	serveReportRun(c, c.Param("id"))
+}
+
+// GET: /api/report-files/:id?token= - Download dari link email (token bertanda tangan & kedaluwarsa, tanpa login)
+func DownloadReportRunByToken(c *gin.Context) {
+	token, err := jwt.Parse(c.Query("token"), func(t *jwt.Token) (interface{}, error) {
+		return jwtKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Link download tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	runID, _ := claims["run_id"].(float64)
+	if claims["purpose"] != "report_download" || strconv.FormatFloat(runID, 'f', 0, 64) != c.Param("id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link download bukan untuk laporan ini"})
+		return
+	}
+	serveReportRun(c, c.Param("id"))
+}
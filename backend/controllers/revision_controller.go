@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"errors"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Entity yang punya riwayat revisi
+var revisionEntities = map[string]bool{"lwp": true, "cycle": true, "work_order": true, "user": true}
+
+// Helper: Ganti nomor lot di semua tabel yang menyimpannya (lot, link genealogy, cycle).
+// Nomor baru harus hasil reservasi layanan nomor lot; reservasi nomor lama dibatalkan (VOID).
+func renameLot(tx *gorm.DB, oldNo, newNo string) error {
+	var count int64
+	tx.Model(&models.Lot{}).Where("lot_no = ?", newNo).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("Nomor lot %s sudah dipakai", newNo)
+	}
+	if err := database.UseLotNumber(tx, newNo); err != nil {
+		return err
+	}
+	if err := database.VoidLotNumber(tx, oldNo); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Lot{}).Where("lot_no = ?", oldNo).Update("lot_no", newNo).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.LotLink{}).Where("child_lot_no = ?", oldNo).Update("child_lot_no", newNo).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.LotLink{}).Where("parent_lot_no = ?", oldNo).Update("parent_lot_no", newNo).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.PerCycle{}).Where("no_lot = ?", oldNo).Update("no_lot", newNo).Error
+}
+
+// Helper: Validasi format tanggal (YYYY-MM-DD) & jam (HH:MM atau HH:MM:SS) laporan LWP
+func validateLWPTimes(tanggal, jamMulai, jamSelesai string) error {
+	if tanggal != "" {
+		if _, err := time.Parse("2006-01-02", tanggal); err != nil {
+			return fmt.Errorf("Format tanggal harus YYYY-MM-DD")
+		}
+	}
+	for label, jam := range map[string]string{"jamMulai": jamMulai, "jamSelesai": jamSelesai} {
+		if jam == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", jam); err == nil {
+			continue
+		}
+		if _, err := time.Parse("15:04:05", jam); err != nil {
+			return fmt.Errorf("Format %s harus HH:MM", label)
+		}
+	}
+	return nil
+}
+
+// Helper: Simpan perubahan laporan LWP sebagai revisi baru + sinkron ke cycle & lot
+func saveLWPRevision(before models.LWPReport, report *models.LWPReport, changedBy, reason string) (*models.Revision, error) {
+	var rev *models.Revision
+	lotUpdates := map[string]interface{}{"mold_code": report.KodePart, "qty": report.HasilOK + report.NG}
+	if report.KodePart != before.KodePart {
+		if itemCode := itemCodeForMold(report.KodePart); itemCode != "" {
+			lotUpdates["item_code"] = itemCode
+			report.ItemCode = itemCode
+		}
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rev, err = database.RecordRevision(tx, "lwp", report.ID, before, report, changedBy, reason)
+		if err != nil {
+			return err
+		}
+		if rev == nil {
+			return nil
+		}
+		report.Revision = rev.Revision
+
+		if report.NoLot != before.NoLot {
+			if err := renameLot(tx, before.NoLot, report.NoLot); err != nil {
+				return err
+			}
+		}
+		// Hanya kolom yang berubah (nama field revisi = kolom tabel), agar rework_ok / review bersamaan tidak tertimpa
+		columns := []string{"revision", "updated_at"}
+		for _, change := range rev.Changes {
+			columns = append(columns, change.Field)
+		}
+		if err := tx.Model(report).Select(columns).Updates(report).Error; err != nil {
+			return err
+		}
+		if report.CycleID != 0 {
+			tx.Model(&models.PerCycle{}).Where("id = ?", report.CycleID).Update("item", report.PartName)
+		}
+		return tx.Model(&models.Lot{}).Where("lot_no = ?", report.NoLot).
+			Updates(lotUpdates).Error
+	})
+	return rev, err
+}
+
+// Helper: Apakah data produksi laporan berubah (di luar status approval)
+func lwpDataChanged(a, b models.LWPReport) bool {
+	return a.Tanggal != b.Tanggal || a.Shift != b.Shift || a.JamMulai != b.JamMulai || a.JamSelesai != b.JamSelesai ||
+		a.HasilOK != b.HasilOK || a.NG != b.NG || a.NoLot != b.NoLot
+}
+
+// POST: /api/lwp/:id/corrections - Koreksi data LWP setelah shift.
+// Laporan APPROVED yang dikoreksi kembali ke SUBMITTED dan harus di-approve ulang.
+func CorrectLWPReport(c *gin.Context) {
+	var report models.LWPReport
+	if err := database.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Laporan LWP tidak ditemukan"})
+		return
+	}
+	if !canReviewProcess(c, report.Proses) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Proses " + report.Proses + " bukan tanggung jawab leader ini"})
+		return
+	}
+
+	var input struct {
+		Reason     string `json:"reason" binding:"required"`
+		Tanggal    string `json:"tanggal"`
+		Shift      string `json:"shift"`
+		JamMulai   string `json:"jamMulai"`
+		JamSelesai string `json:"jamSelesai"`
+		HasilOk    *int   `json:"hasilOk"`
+		Ng         *int   `json:"ng"`
+		NoLot      string `json:"noLot"`
+
+		KlasifikasiReject *[]LWPRejectInput `json:"klasifikasiReject"` // nil = klasifikasi lama tetap
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan koreksi (reason) wajib diisi"})
+		return
+	}
+	if err := validateLWPTimes(input.Tanggal, input.JamMulai, input.JamSelesai); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := report
+	if input.Tanggal != "" {
+		report.Tanggal = input.Tanggal
+	}
+	if input.Shift != "" {
+		report.Shift = input.Shift
+	}
+	if input.JamMulai != "" {
+		report.JamMulai = input.JamMulai
+	}
+	if input.JamSelesai != "" {
+		report.JamSelesai = input.JamSelesai
+	}
+	if input.HasilOk != nil {
+		report.HasilOK = *input.HasilOk
+	}
+	if input.Ng != nil {
+		report.NG = *input.Ng
+	}
+	if input.NoLot != "" {
+		report.NoLot = input.NoLot
+	}
+	rejects, err := validateLWPQuantities(report, input.KlasifikasiReject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkDispositionedNG(c, report) {
+		return
+	}
+
+	// Laporan APPROVED yang dikoreksi harus di-approve ulang; approval lama tidak berlaku untuk data baru
+	reapproval := report.Status == "APPROVED" && lwpDataChanged(before, report)
+	if reapproval {
+		now := time.Now()
+		report.Status = "SUBMITTED"
+		report.SubmittedAt = &now
+		report.ReviewedBy = ""
+		report.ReviewedAt = nil
+		report.ReviewComment = ""
+	}
+
+	rev, err := saveLWPRevision(before, &report, currentNIK(c), input.Reason)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if rejects != nil {
+		if err := saveLWPDefects(database.DB, report.ID, rejects); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan klasifikasi reject"})
+			return
+		}
+	}
+	if rev == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Tidak ada perubahan", "data": report})
+		return
+	}
+
+	recordAuditChange(c, "CORRECT_LWP", "lwp", report.ID, before, report,
+		fmt.Sprintf("rev %d: %s", rev.Revision, input.Reason))
+	if reapproval {
+		notifyLWPSubmitted(report)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Koreksi LWP tersimpan", "data": report, "revision": rev})
+}
+
+// POST: /api/cycles/:id/corrections - Koreksi data cycle (item, lot, status mesin)
+func CorrectCycle(c *gin.Context) {
+	var cycle models.PerCycle
+	if err := database.DB.First(&cycle, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data cycle tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Reason      string `json:"reason" binding:"required"`
+		Item        string `json:"item"`
+		NoLot       string `json:"no_lot"`
+		StatusMesin string `json:"status_mesin"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan koreksi (reason) wajib diisi"})
+		return
+	}
+
+	// Cycle yang berasal dari LWP dikoreksi lewat laporan LWP-nya agar lot & qty ikut konsisten
+	var count int64
+	database.DB.Model(&models.LWPReport{}).Where("cycle_id = ?", cycle.ID).Count(&count)
+	if count > 0 && input.NoLot != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cycle ini milik laporan LWP, koreksi lot lewat /api/lwp/:id/corrections"})
+		return
+	}
+
+	before := cycle
+	if input.Item != "" {
+		cycle.Item = input.Item
+	}
+	if input.NoLot != "" {
+		cycle.NoLot = input.NoLot
+	}
+	if input.StatusMesin != "" {
+		cycle.StatusMesin = input.StatusMesin
+	}
+
+	var rev *models.Revision
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rev, err = database.RecordRevision(tx, "cycle", cycle.ID, before, cycle, currentNIK(c), input.Reason)
+		if err != nil || rev == nil {
+			return err
+		}
+		cycle.Revision = rev.Revision
+		return tx.Save(&cycle).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan koreksi: " + err.Error()})
+		return
+	}
+	if rev == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Tidak ada perubahan", "data": cycle})
+		return
+	}
+
+	recordAuditChange(c, "CORRECT_CYCLE", "cycle", cycle.ID, before, cycle,
+		fmt.Sprintf("rev %d: %s", rev.Revision, input.Reason))
+	c.JSON(http.StatusOK, gin.H{"message": "Koreksi cycle tersimpan", "data": cycle, "revision": rev})
+}
+
+// GET: /api/revisions/:entity/:id - Riwayat perubahan satu record (lwp, cycle, work_order, user)
+func GetRevisionHistory(c *gin.Context) {
+	entity := c.Param("entity")
+	if !revisionEntities[entity] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Entity tidak dikenal: " + entity})
+		return
+	}
+
+	var current interface{}
+	var err error
+	switch entity {
+	case "lwp":
+		var r models.LWPReport
+		err = database.DB.First(&r, c.Param("id")).Error
+		current = r
+	case "cycle":
+		var r models.PerCycle
+		err = database.DB.First(&r, c.Param("id")).Error
+		current = r
+	case "work_order":
+		var r models.WorkOrder
+		err = database.DB.First(&r, c.Param("id")).Error
+		current = r
+	case "user":
+		var r models.User
+		err = database.DB.First(&r, c.Param("id")).Error
+		current = r
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record tidak ditemukan"})
+		return
+	}
+
+	var revisions []models.Revision
+	database.DB.Where("entity_type = ? AND entity_id = ?", entity, c.Param("id")).
+		Order("revision asc").Find(&revisions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"entity":    entity,
+		"current":   current,
+		"total":     len(revisions),
+		"revisions": revisions,
+	})
+}
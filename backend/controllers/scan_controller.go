@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Struct input scan dari perangkat (HP operator / scanner di mesin)
+type ScanInput struct {
+	Type      string `json:"type" binding:"required,oneof=MACHINE LOT BADGE"`
+	Code      string `json:"code" binding:"required"`
+	DeviceID  string `json:"device_id"`
+	NoMC      string `json:"no_mc"`     // Opsional, default: scan MACHINE terakhir di device ini
+	Action    string `json:"action"`    // Opsional START / STOP, default: otomatis
+	Timestamp string `json:"timestamp"` // Opsional RFC3339, default: waktu server
+}
+
+// Helper: Scan terakhir dengan tipe tertentu dari device yang sama (hari ini)
+func lastDeviceScan(deviceID, scanType string, day time.Time) *models.ScanEvent {
+	if deviceID == "" {
+		return nil
+	}
+	var scan models.ScanEvent
+	err := database.DB.Where("device_id = ? AND type = ? AND valid = ? AND scanned_at >= ?",
+		deviceID, scanType, true, day).Order("scanned_at desc").First(&scan).Error
+	if err != nil {
+		return nil
+	}
+	return &scan
+}
+
+// Helper: Scan START yang belum ada STOP-nya untuk lot di mesin tertentu
+func openLotScan(lotNo, noMC string) *models.ScanEvent {
+	var start models.ScanEvent
+	err := database.DB.Where("type = ? AND valid = ? AND lot_no = ? AND no_mc = ? AND action = ?",
+		"LOT", true, lotNo, noMC, "START").Order("scanned_at desc").First(&start).Error
+	if err != nil {
+		return nil
+	}
+	var stops int64
+	database.DB.Model(&models.ScanEvent{}).Where("type = ? AND valid = ? AND lot_no = ? AND no_mc = ? AND action = ? AND scanned_at >= ?",
+		"LOT", true, lotNo, noMC, "STOP", start.ScannedAt).Count(&stops)
+	if stops > 0 {
+		return nil
+	}
+	return &start
+}
+
+// Helper: Mesin tempat lot sedang berjalan (START tanpa STOP), kosong jika tidak ada
+func lotRunningOn(lotNo string) string {
+	var machines []string
+	database.DB.Model(&models.ScanEvent{}).Where("type = ? AND valid = ? AND lot_no = ? AND action = ?",
+		"LOT", true, lotNo, "START").Distinct().Pluck("no_mc", &machines)
+	for _, noMC := range machines {
+		if openLotScan(lotNo, noMC) != nil {
+			return noMC
+		}
+	}
+	return ""
+}
+
+// Helper: Validasi scan lot -> lot ada, milik WO yang dijadwalkan di mesin ini, belum terpakai
+func validateLotScan(scan *models.ScanEvent) error {
+	if scan.NoMC == "" {
+		return fmt.Errorf("scan mesin dulu sebelum scan lot")
+	}
+
+	payload, err := parseLabelPayload(scan.Code)
+	if err != nil {
+		return err
+	}
+	scan.LotNo = payload.LotNo
+
+	if scan.Action != "START" && scan.Action != "STOP" {
+		return fmt.Errorf("action %q tidak dikenal (START / STOP)", scan.Action)
+	}
+
+	// STOP untuk lot yang sedang jalan tidak perlu divalidasi ulang
+	if scan.Action == "STOP" {
+		if openLotScan(scan.LotNo, scan.NoMC) == nil {
+			return fmt.Errorf("lot %s belum di-START di mesin %s", scan.LotNo, scan.NoMC)
+		}
+		return nil
+	}
+
+	// Lot yang masih berjalan tidak boleh di-START lagi (di mesin ini maupun mesin lain)
+	if running := lotRunningOn(scan.LotNo); running != "" {
+		return fmt.Errorf("lot %s masih berjalan di mesin %s, STOP dulu", scan.LotNo, running)
+	}
+
+	var woID uint
+	var lot models.Lot
+	if err := database.DB.Where("lot_no = ?", scan.LotNo).First(&lot).Error; err == nil {
+		woID = lot.WorkOrderID
+	} else {
+		var res models.LotReservation
+		if err := database.DB.Where("lot_no = ? AND status <> ?", scan.LotNo, "VOID").First(&res).Error; err != nil {
+			return fmt.Errorf("lot %s tidak terdaftar", scan.LotNo)
+		}
+		woID = res.WorkOrderID
+	}
+
+	// Lot sudah terpakai jika sudah jadi induk lot lain; STOP sebelumnya hanya jeda (boleh START lagi)
+	var used int64
+	database.DB.Model(&models.LotLink{}).Where("parent_lot_no = ?", scan.LotNo).Count(&used)
+	if used > 0 {
+		return fmt.Errorf("lot %s sudah terpakai", scan.LotNo)
+	}
+
+	var schedule models.Schedule
+	err = database.DB.Where("no_mc = ? AND status IN ? AND planned_start <= ? AND planned_end >= ?",
+		scan.NoMC, []string{"PLANNED", "RUNNING"}, scan.ScannedAt, scan.ScannedAt).
+		Order("planned_start asc").First(&schedule).Error
+	if err != nil {
+		return fmt.Errorf("tidak ada WO yang dijadwalkan di mesin %s saat ini", scan.NoMC)
+	}
+	if woID != 0 && woID != schedule.WorkOrderID {
+		return fmt.Errorf("lot %s bukan milik WO yang dijadwalkan di mesin %s", scan.LotNo, scan.NoMC)
+	}
+	scan.ScheduleID = schedule.ID
+	return nil
+}
+
+// Helper: Waktu scan dari input (RFC3339), default waktu server
+func scanTime(timestamp string) (time.Time, error) {
+	if timestamp == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return t, fmt.Errorf("Format timestamp harus RFC3339 (contoh 2026-02-01T08:00:00+07:00)")
+	}
+	return t, nil
+}
+
+// Helper: Validasi & simpan satu scan. Scan invalid tetap disimpan untuk jejak.
+func recordScan(c *gin.Context, input ScanInput, scannedAt time.Time) (models.ScanEvent, error) {
+	y, m, d := scannedAt.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, scannedAt.Location())
+
+	scan := models.ScanEvent{
+		Type:      input.Type,
+		Code:      strings.TrimSpace(input.Code),
+		DeviceID:  input.DeviceID,
+		NoMC:      input.NoMC,
+		Action:    strings.ToUpper(input.Action),
+		ScannedAt: scannedAt,
+		NIK:       currentNIK(c),
+	}
+
+	// Operator yang terakhir scan badge di device ini dianggap pelaku scan
+	if badge := lastDeviceScan(input.DeviceID, "BADGE", day); badge != nil {
+		scan.NIK = badge.NIK
+	}
+
+	var verr error
+	switch scan.Type {
+	case "MACHINE":
+		var machine models.Machine
+		if err := database.DB.Where("no_mc = ? AND aktif = ?", scan.Code, true).First(&machine).Error; err != nil {
+			verr = fmt.Errorf("mesin %s tidak terdaftar / tidak aktif", scan.Code)
+		}
+		scan.NoMC = scan.Code
+	case "BADGE":
+		scan.NIK = scan.Code
+		// Badge bisa berisi NIK atau NPK lama, diseragamkan ke NIK
+		if operator, err := database.ResolveOperator(scan.Code); err != nil {
+			verr = fmt.Errorf("badge %s tidak terdaftar", scan.Code)
+		} else {
+			scan.NIK = operator.NIK
+		}
+	case "LOT":
+		if scan.NoMC == "" {
+			if mc := lastDeviceScan(input.DeviceID, "MACHINE", day); mc != nil {
+				scan.NoMC = mc.NoMC
+			}
+		}
+		if scan.Action == "" {
+			payload, _ := parseLabelPayload(scan.Code)
+			scan.Action = "START"
+			if openLotScan(payload.LotNo, scan.NoMC) != nil {
+				scan.Action = "STOP"
+			}
+		}
+		verr = validateLotScan(&scan)
+	}
+
+	scan.Valid = verr == nil
+	if verr != nil {
+		scan.Message = verr.Error()
+	} else {
+		scan.Message = "OK"
+	}
+
+	if err := database.DB.Create(&scan).Error; err != nil {
+		return scan, fmt.Errorf("gagal menyimpan scan: %w", err)
+	}
+	return scan, verr
+}
+
+// Helper: Susun pasangan START/STOP scan lot untuk tabel scans di dashboard pressing
+func derivePressingScans(from, to time.Time, noMC string) []PressingScan {
+	var events []models.ScanEvent
+	query := database.DB.Where("type = ? AND valid = ? AND scanned_at >= ? AND scanned_at < ?", "LOT", true, from, to)
+	if noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	query.Order("scanned_at asc").Find(&events)
+
+	scans := []PressingScan{}
+	open := make(map[string]int) // key lot|mesin -> index di scans
+	for _, e := range events {
+		key := e.LotNo + "|" + e.NoMC
+		switch e.Action {
+		case "START":
+			open[key] = len(scans)
+			scans = append(scans, PressingScan{
+				ID:        e.ID,
+				Lot:       e.LotNo,
+				TimeStart: e.ScannedAt.Format("15:04"),
+				TimeEnd:   "-",
+				Status:    "Proses",
+				Pic:       e.NIK,
+			})
+		case "STOP":
+			if idx, ok := open[key]; ok {
+				scans[idx].TimeEnd = e.ScannedAt.Format("15:04")
+				scans[idx].Status = "Selesai"
+				delete(open, key)
+			}
+		}
+	}
+	return scans
+}
+
+// POST: /api/scans - Terima scan mesin / lot / badge
+func CreateScan(c *gin.Context) {
+	var input ScanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	scannedAt, err := scanTime(input.Timestamp)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scan, err := recordScan(c, input, scannedAt)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": scan})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Scan diterima", "data": scan})
+}
+
+// GET: /api/scans?tanggal=2026-02-01&no_mc=04A&device_id=X&valid=false
+func GetScans(c *gin.Context) {
+	tanggal := c.DefaultQuery("tanggal", time.Now().Format("2006-01-02"))
+	day, err := time.ParseInLocation("2006-01-02", tanggal, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal salah (gunakan YYYY-MM-DD)"})
+		return
+	}
+
+	query := database.DB.Where("scanned_at >= ? AND scanned_at < ?", day, day.AddDate(0, 0, 1))
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	if device := c.Query("device_id"); device != "" {
+		query = query.Where("device_id = ?", device)
+	}
+	if valid := c.Query("valid"); valid != "" {
+		query = query.Where("valid = ?", valid == "true")
+	}
+
+	var scans []models.ScanEvent
+	query.Order("scanned_at desc").Find(&scans)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(scans),
+		"data":  scans,
+		"pairs": derivePressingScans(day, day.AddDate(0, 0, 1), c.Query("no_mc")),
+	})
+}
@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Struct input untuk membuat jadwal produksi
+type ScheduleInput struct {
+	WorkOrderID uint   `json:"work_order_id" binding:"required"`
+	NoMC        string `json:"no_mc" binding:"required"`
+	ItemCode    string `json:"item_code"` // Default: item code WO
+	MoldCode    string `json:"mold_code"`
+	Tanggal     string `json:"tanggal" binding:"required"` // YYYY-MM-DD
+	Shift       string `json:"shift" binding:"required,oneof=1 2 3"`
+	JamMulai    string `json:"jam_mulai"`   // Opsional HH:MM, default awal shift
+	JamSelesai  string `json:"jam_selesai"` // Opsional HH:MM, default akhir shift
+	Qty         int    `json:"qty"`         // Default: sisa qty WO
+}
+
+// Hasil pengecekan bentrok & kapasitas
+type ScheduleConflict struct {
+	ScheduleID uint   `json:"schedule_id"`
+	NoMC       string `json:"no_mc"`
+	Tanggal    string `json:"tanggal"`
+	Shift      string `json:"shift"`
+	Type       string `json:"type"` // OVERLAP, CAPACITY
+	Message    string `json:"message"`
+}
+
+// Helper: Hitung waktu mulai/selesai jadwal dari tanggal + shift (+ jam opsional)
+func scheduleWindow(tanggal, shift, jamMulai, jamSelesai string) (time.Time, time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", tanggal, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("format tanggal salah (gunakan YYYY-MM-DD)")
+	}
+
+	startHour, endHour := shiftHours(shift)
+	start := day.Add(time.Duration(startHour) * time.Hour)
+	end := day.Add(time.Duration(endHour) * time.Hour)
+
+	if jamMulai != "" {
+		t, err := time.Parse("15:04", jamMulai)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("format jam_mulai salah (gunakan HH:MM)")
+		}
+		start = day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	if jamSelesai != "" {
+		t, err := time.Parse("15:04", jamSelesai)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("format jam_selesai salah (gunakan HH:MM)")
+		}
+		end = day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("jam selesai harus setelah jam mulai")
+	}
+	return start, end, nil
+}
+
+// Helper: Cek bentrok jadwal di mesin yang sama & kapasitas shift berdasarkan tgtQtyPJam
+func checkScheduleConflicts(s models.Schedule) []ScheduleConflict {
+	var conflicts []ScheduleConflict
+
+	// 1. Overlap waktu di mesin yang sama
+	var overlaps []models.Schedule
+	database.DB.Where("no_mc = ? AND id <> ? AND status <> ? AND planned_start < ? AND planned_end > ?",
+		s.NoMC, s.ID, "CANCELLED", s.PlannedEnd, s.PlannedStart).Find(&overlaps)
+
+	for _, o := range overlaps {
+		conflicts = append(conflicts, ScheduleConflict{
+			ScheduleID: s.ID,
+			NoMC:       s.NoMC,
+			Tanggal:    s.Tanggal,
+			Shift:      s.Shift,
+			Type:       "OVERLAP",
+			Message: fmt.Sprintf("Bentrok dengan jadwal #%d (%s - %s)", o.ID,
+				o.PlannedStart.Format("15:04"), o.PlannedEnd.Format("15:04")),
+		})
+	}
+
+	// 2. Kapasitas: jam yang dibutuhkan (qty / target per jam) vs jam yang tersedia
+	tgt := getTargetPerJam(s.ItemCode, s.MoldCode, s.NoMC, s.Tanggal)
+	if tgt > 0 && s.Qty > 0 {
+		neededHours := float64(s.Qty) / tgt
+		plannedHours := s.PlannedEnd.Sub(s.PlannedStart).Hours()
+		if neededHours > plannedHours {
+			conflicts = append(conflicts, ScheduleConflict{
+				ScheduleID: s.ID,
+				NoMC:       s.NoMC,
+				Tanggal:    s.Tanggal,
+				Shift:      s.Shift,
+				Type:       "CAPACITY",
+				Message: fmt.Sprintf("Qty %d butuh %.1f jam (target %.0f/jam), slot hanya %.1f jam",
+					s.Qty, neededHours, tgt, plannedHours),
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// POST: /api/schedule - Alokasikan WO ke mesin & shift
+func CreateSchedule(c *gin.Context) {
+	var input ScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Input tidak valid: " + err.Error()})
+		return
+	}
+
+	var wo models.WorkOrder
+	if err := database.DB.First(&wo, input.WorkOrderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work Order tidak ditemukan"})
+		return
+	}
+
+	var machine models.Machine
+	if err := database.DB.Where("no_mc = ? AND aktif = ?", input.NoMC, true).First(&machine).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mesin tidak terdaftar / tidak aktif"})
+		return
+	}
+
+	start, end, err := scheduleWindow(input.Tanggal, input.Shift, input.JamMulai, input.JamSelesai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	qty := input.Qty
+	if qty <= 0 {
+		qty = wo.Quantity
+	}
+	// Tanpa item code, cek kapasitas (tgtQtyPJam) tidak bisa dihitung
+	if input.ItemCode == "" {
+		input.ItemCode = wo.ItemCode
+	}
+
+	schedule := models.Schedule{
+		WorkOrderID:  wo.ID,
+		NoMC:         machine.NoMC,
+		ItemCode:     input.ItemCode,
+		MoldCode:     input.MoldCode,
+		Tanggal:      input.Tanggal,
+		Shift:        input.Shift,
+		PlannedStart: start,
+		PlannedEnd:   end,
+		Qty:          qty,
+		Status:       "PLANNED",
+		CreatedBy:    currentNIK(c),
+	}
+
+	// Overlap = ditolak, kapasitas lebih = tetap disimpan tapi diberi peringatan
+	conflicts := checkScheduleConflicts(schedule)
+	for _, cf := range conflicts {
+		if cf.Type == "OVERLAP" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Jadwal bentrok di mesin " + machine.NoMC, "conflicts": conflicts})
+			return
+		}
+	}
+
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan jadwal"})
+		return
+	}
+
+	recordAudit(c, "CREATE_SCHEDULE",
+		fmt.Sprintf("%s -> MC %s (%s shift %s)", wo.OrderNumber, schedule.NoMC, schedule.Tanggal, schedule.Shift))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Jadwal berhasil dibuat",
+		"data":     schedule,
+		"warnings": conflicts,
+	})
+}
+
+// GET: /api/schedule?tanggal=2026-02-01&no_mc=04A
+func GetSchedules(c *gin.Context) {
+	var schedules []models.Schedule
+
+	query := database.DB.Preload("WorkOrder").Order("planned_start asc")
+	if tanggal := c.Query("tanggal"); tanggal != "" {
+		query = query.Where("tanggal = ?", tanggal)
+	}
+	if noMC := c.Query("no_mc"); noMC != "" {
+		query = query.Where("no_mc = ?", noMC)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil jadwal"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": len(schedules), "data": schedules})
+}
+
+// GET: /api/schedule/conflicts?tanggal=2026-02-01 - Daftar bentrok & kapasitas lebih
+func GetScheduleConflicts(c *gin.Context) {
+	tanggal := c.Query("tanggal")
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+
+	var schedules []models.Schedule
+	database.DB.Where("tanggal = ? AND status <> ?", tanggal, "CANCELLED").Order("no_mc, planned_start").Find(&schedules)
+
+	conflicts := []ScheduleConflict{}
+	for _, s := range schedules {
+		conflicts = append(conflicts, checkScheduleConflicts(s)...)
+	}
+
+	// Kapasitas total per mesin per shift (jumlah jam kebutuhan semua WO)
+	type slotKey struct{ NoMC, Shift string }
+	neededPerSlot := make(map[slotKey]float64)
+	for _, s := range schedules {
+		if tgt := getTargetPerJam(s.ItemCode, s.MoldCode, s.NoMC, s.Tanggal); tgt > 0 {
+			neededPerSlot[slotKey{s.NoMC, s.Shift}] += float64(s.Qty) / tgt
+		}
+	}
+	for key, needed := range neededPerSlot {
+		startHour, endHour := shiftHours(key.Shift)
+		if available := float64(endHour - startHour); needed > available {
+			conflicts = append(conflicts, ScheduleConflict{
+				NoMC:    key.NoMC,
+				Tanggal: tanggal,
+				Shift:   key.Shift,
+				Type:    "CAPACITY",
+				Message: fmt.Sprintf("Total kebutuhan %.1f jam melebihi kapasitas shift %.0f jam", needed, available),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tanggal": tanggal, "total": len(conflicts), "data": conflicts})
+}
+
+// Perpindahan status jadwal yang diizinkan: PLANNED -> RUNNING -> DONE, CANCELLED dari PLANNED / RUNNING
+var scheduleTransitions = map[string][]string{
+	"PLANNED": {"RUNNING", "CANCELLED"},
+	"RUNNING": {"DONE", "CANCELLED"},
+}
+
+// Helper: Cek apakah status jadwal boleh berpindah dari -> ke
+func canTransitionSchedule(from, to string) bool {
+	for _, next := range scheduleTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PATCH: /api/schedule/:id/status - PLANNED -> RUNNING -> DONE / CANCELLED
+func UpdateScheduleStatus(c *gin.Context) {
+	var schedule models.Schedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jadwal tidak ditemukan"})
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=PLANNED RUNNING DONE CANCELLED"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status harus PLANNED, RUNNING, DONE atau CANCELLED"})
+		return
+	}
+	if !canTransitionSchedule(schedule.Status, input.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Status jadwal tidak bisa berubah dari %s ke %s", schedule.Status, input.Status)})
+		return
+	}
+
+	// WO tidak boleh mulai jalan di mesin yang PM kritisnya overdue
+	var warnings []PMTaskStatus
+	if input.Status == "RUNNING" {
+		var blocked bool
+		if blocked, warnings = abortIfPMBlocked(c, schedule.NoMC); blocked {
+			return
+		}
+	}
+
+	oldStatus := schedule.Status
+	schedule.Status = input.Status
+	if err := database.DB.Save(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan status jadwal"})
+		return
+	}
+
+	recordAudit(c, "UPDATE_SCHEDULE_STATUS",
+		fmt.Sprintf("#%d %s -> %s", schedule.ID, oldStatus, input.Status))
+
+	// Sinkronkan status mesin: mulai jalan -> RUNNING, selesai/batal -> kembali IDLE (kecuali sedang rusak / PM)
+	switch {
+	case input.Status == "RUNNING":
+		setMachineStatus(schedule.NoMC, "RUNNING")
+	case oldStatus == "RUNNING":
+		var machine models.Machine
+		if database.DB.Where("no_mc = ?", schedule.NoMC).First(&machine).Error == nil && machine.Status == "RUNNING" {
+			setMachineStatus(schedule.NoMC, machineReadyStatus(schedule.NoMC))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status jadwal diperbarui", "data": schedule, "warnings": warnings})
+}
+
+// DELETE: /api/schedule/:id - Hanya jadwal PLANNED / CANCELLED (yang sudah jalan dihentikan lewat status)
+func DeleteSchedule(c *gin.Context) {
+	id := c.Param("id")
+	var schedule models.Schedule
+	if err := database.DB.First(&schedule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jadwal tidak ditemukan"})
+		return
+	}
+	if schedule.Status != "PLANNED" && schedule.Status != "CANCELLED" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Jadwal " + schedule.Status + " tidak bisa dihapus, hanya PLANNED / CANCELLED"})
+		return
+	}
+	res := database.DB.Where("status IN ?", []string{"PLANNED", "CANCELLED"}).Delete(&schedule)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus jadwal"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Status jadwal sudah berubah, muat ulang"})
+		return
+	}
+
+	recordAudit(c, "DELETE_SCHEDULE", "#"+id)
+	c.JSON(http.StatusOK, gin.H{"message": "Jadwal berhasil dihapus"})
+}
+
+// GET: /api/machines/:no_mc/queue - Antrian WO untuk operator di mesin
+func GetMachineQueue(c *gin.Context) {
+	noMC := c.Param("no_mc")
+	today := time.Now().Format("2006-01-02")
+
+	var queue []models.Schedule
+	if err := database.DB.Preload("WorkOrder").
+		Where("no_mc = ? AND status IN ? AND tanggal >= ?", noMC, []string{"PLANNED", "RUNNING"}, today).
+		Order("planned_start asc").
+		Find(&queue).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil antrian mesin"})
+		return
+	}
+
+	var next *models.Schedule
+	if len(queue) > 0 {
+		next = &queue[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"no_mc": noMC,
+		"next":  next,
+		"total": len(queue),
+		"queue": queue,
+	})
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"factory-api/process"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rentang scorecard maksimal (hari)
+const scorecardMaxDays = 186
+
+// Satu baris LWP untuk scorecard: data pressing + proses, NPK, jam kerja & standar
+type scorecardRow struct {
+	PressingLWPRecord
+	NPK      string
+	Proses   string
+	Hours    float64
+	Standard float64
+}
+
+// Query LWP semua operator dalam rentang tanggal. Standar = jam kerja x target per jam (override / v_stdlot).
+// Kolom reject dari katalog (defectQtysSelect), dibaca ke PressingLWPRecord.DefectQtys; sumber = process.SourceWith.
+const scorecardQuery = `
+	SELECT
+		COALESCE(t.NPK, '') AS npk,
+		COALESCE(t.nama, '') AS nama,
+		COALESCE(t.proses, '') AS proses,
+		t.noMC AS no_mc,
+		DATE_FORMAT(t.tanggal, '%%Y-%%m-%%d') AS tanggal,
+		COALESCE(t.shift, '') AS shift,
+		COALESCE(t.itemCode, '-') AS item_code,
+		COALESCE(t.moldCode, '') AS mold_code,
+		COALESCE(TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0, 0) AS hours,
+		COALESCE((TIME_TO_SEC(TIMEDIFF(t.SELESAI, t.MULAI)) / 3600.0) * COALESCE(om.tgtQtyPJam, o.tgtQtyPJam, s.tgtQtyPJam), 0) AS standard,
+		COALESCE(t.OK, 0) AS ok,
+		COALESCE(t.NG, 0) AS ng,
+		COALESCE(t.Total, 0) AS total,
+		%s AS defect_qtys
+	FROM %s t
+	%s
+	WHERE t.tanggal >= ? AND t.tanggal < ?%s
+`
+
+// Komposisi NG per jenis reject
+type DefectShare struct {
+	Jenis  string  `json:"jenis"`
+	Qty    int     `json:"qty"`
+	Persen float64 `json:"persen"` // % dari total NG
+}
+
+// Ringkasan harian satu operator
+type ScorecardDay struct {
+	Tanggal  string  `json:"tanggal"`
+	Hours    float64 `json:"hours"`
+	Output   int     `json:"output"`
+	Standard float64 `json:"standard"`
+	OK       int     `json:"ok"`
+	NG       int     `json:"ng"`
+}
+
+// Scorecard operator untuk satu proses dalam satu periode
+type OperatorScorecard struct {
+	NIK         string         `json:"nik"`
+	Nama        string         `json:"nama"`
+	Proses      string         `json:"proses"`
+	DaysWorked  int            `json:"days_worked"`
+	Hours       float64        `json:"hours"`
+	Output      int            `json:"output"`      // Total (OK + NG)
+	Standard    float64        `json:"standard"`    // Jam kerja x tgtQtyPJam
+	Performance float64        `json:"performance"` // Output / standar (%)
+	OK          int            `json:"ok"`
+	NG          int            `json:"ng"`
+	FPY         float64        `json:"fpy"` // First-pass yield: OK / output (%)
+	DefectMix   []DefectShare  `json:"defect_mix"`
+	Machines    []string       `json:"machines"`
+	Items       []string       `json:"items"`
+	Daily       []ScorecardDay `json:"daily,omitempty"`
+	Rank        int            `json:"rank"`    // Peringkat dalam proses (1 = terbaik)
+	RankOf      int            `json:"rank_of"` // Jumlah operator dalam proses
+}
+
+// Helper: Key map yang sudah diurutkan
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Helper: Agregasi baris LWP menjadi scorecard per NIK + proses, lalu beri peringkat per proses.
+// Peringkat: performance (output vs standar) lalu FPY.
+func buildScorecards(rows []scorecardRow, withDaily bool) []OperatorScorecard {
+	type acc struct {
+		card     *OperatorScorecard
+		days     map[string]*ScorecardDay
+		machines map[string]bool
+		items    map[string]bool
+		defects  map[string]int
+	}
+	accs := map[string]*acc{}
+	order := []string{}
+
+	for _, r := range rows {
+		if r.NPK == "" {
+			continue
+		}
+		key := r.NPK + "|" + r.Proses
+		a, ok := accs[key]
+		if !ok {
+			a = &acc{
+				card:     &OperatorScorecard{NIK: r.NPK, Nama: r.Nama, Proses: r.Proses},
+				days:     map[string]*ScorecardDay{},
+				machines: map[string]bool{},
+				items:    map[string]bool{},
+				defects:  map[string]int{},
+			}
+			accs[key] = a
+			order = append(order, key)
+		}
+
+		card := a.card
+		card.Hours += r.Hours
+		card.Output += r.Total
+		card.Standard += r.Standard
+		card.OK += r.OK
+		card.NG += r.NG
+		if r.NoMC != "" {
+			a.machines[r.NoMC] = true
+		}
+		if r.ItemCode != "" && r.ItemCode != "-" {
+			a.items[r.ItemCode] = true
+		}
+		for _, rej := range r.rejectQtys() {
+			if rej.Qty > 0 {
+				a.defects[rej.Nama] += rej.Qty
+			}
+		}
+
+		day, ok := a.days[r.Tanggal]
+		if !ok {
+			day = &ScorecardDay{Tanggal: r.Tanggal}
+			a.days[r.Tanggal] = day
+		}
+		day.Hours += r.Hours
+		day.Output += r.Total
+		day.Standard += r.Standard
+		day.OK += r.OK
+		day.NG += r.NG
+	}
+
+	cards := []OperatorScorecard{}
+	for _, key := range order {
+		a := accs[key]
+		card := a.card
+		card.DaysWorked = len(a.days)
+		card.Performance = exportRatio(float64(card.Output), card.Standard) * 100
+		card.FPY = exportRatio(float64(card.OK), float64(card.Output)) * 100
+		card.Machines = sortedKeys(a.machines)
+		card.Items = sortedKeys(a.items)
+
+		card.DefectMix = []DefectShare{}
+		for jenis, qty := range a.defects {
+			card.DefectMix = append(card.DefectMix, DefectShare{
+				Jenis: jenis, Qty: qty, Persen: exportRatio(float64(qty), float64(card.NG)) * 100,
+			})
+		}
+		sort.Slice(card.DefectMix, func(i, j int) bool { return card.DefectMix[i].Qty > card.DefectMix[j].Qty })
+
+		if withDaily {
+			for _, d := range a.days {
+				card.Daily = append(card.Daily, *d)
+			}
+			sort.Slice(card.Daily, func(i, j int) bool { return card.Daily[i].Tanggal < card.Daily[j].Tanggal })
+		}
+		cards = append(cards, *card)
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		if cards[i].Proses != cards[j].Proses {
+			return cards[i].Proses < cards[j].Proses
+		}
+		if cards[i].Performance != cards[j].Performance {
+			return cards[i].Performance > cards[j].Performance
+		}
+		return cards[i].FPY > cards[j].FPY
+	})
+	count := map[string]int{}
+	for _, card := range cards {
+		count[card.Proses]++
+	}
+	rank := map[string]int{}
+	for i := range cards {
+		rank[cards[i].Proses]++
+		cards[i].Rank = rank[cards[i].Proses]
+		cards[i].RankOf = count[cards[i].Proses]
+	}
+	return cards
+}
+
+// Helper: Ambil baris scorecard untuk periode (opsional filter proses)
+func scorecardRows(from, to time.Time, proses string) ([]scorecardRow, error) {
+	join, args := standardJoin(from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	args = append(args, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	filter := ""
+	if proses != "" {
+		filter = " AND t.proses = ?"
+		args = append(args, proses)
+	}
+
+	// Setiap proses terdaftar dibaca dari tabelnya sendiri dengan katalog reject masing-masing
+	var rows []scorecardRow
+	for _, def := range process.All() {
+		if proses != "" && !def.HasLabel(proses) {
+			continue
+		}
+		defects := database.DefectTypes(def.Code, false)
+		var part []scorecardRow
+		query := fmt.Sprintf(scorecardQuery, defectQtysSelect("t", defects), def.SourceWith(defectColumns(defects)...), join, filter)
+		if err := database.MySQL.Raw(query, args...).Scan(&part).Error; err != nil {
+			return nil, fmt.Errorf("proses %s: %w", def.Code, err)
+		}
+		for i := range part {
+			part[i].Rejects = parseRejects(part[i].DefectQtys, defects)
+		}
+		rows = append(rows, part...)
+	}
+
+	// NPK (termasuk NPK lama) -> NIK & nama tampilan dari tm_employ
+	identities := map[string]models.OperatorIdentity{}
+	for i := range rows {
+		if rows[i].NPK == "" {
+			continue
+		}
+		identity := resolveRowOperator(identities, rows[i].NPK, rows[i].Nama)
+		rows[i].NPK = identity.NIK
+		rows[i].Nama = identity.Nama
+	}
+	return rows, nil
+}
+
+// Helper: Operator hanya boleh melihat scorecard miliknya sendiri
+func isOperatorRole(role string) bool {
+	switch strings.ToUpper(role) {
+	case "ADMIN", "MANAGER", "LEADER":
+		return false
+	}
+	return true
+}
+
+// Helper: Validasi periode scorecard (default 30 hari terakhir)
+func scorecardPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return from, to, false
+	}
+	if to.Sub(from).Hours()/24 > scorecardMaxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Rentang scorecard maksimal %d hari", scorecardMaxDays)})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// GET: /api/operators/scorecard?from=&to=&proses= - Scorecard & peringkat semua operator
+func GetOperatorScorecards(c *gin.Context) {
+	if isOperatorRole(currentRole(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hanya leader/manager yang bisa melihat peringkat operator"})
+		return
+	}
+	if !isDBConnected(c) {
+		return
+	}
+	from, to, ok := scorecardPeriod(c)
+	if !ok {
+		return
+	}
+
+	rows, err := scorecardRows(from, to, c.Query("proses"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data scorecard: " + err.Error()})
+		return
+	}
+
+	cards := buildScorecards(rows, false)
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from.Format("2006-01-02"),
+		"to":    to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"total": len(cards),
+		"data":  cards,
+	})
+}
+
+// GET: /api/operators/:nik/scorecard?from=&to= - Scorecard satu operator (per proses + harian)
+func GetOperatorScorecard(c *gin.Context) {
+	if !isDBConnected(c) {
+		return
+	}
+	operator, ok := resolveOperatorParam(c, c.Param("nik"))
+	if !ok {
+		return
+	}
+	nik := operator.NIK
+	if isOperatorRole(currentRole(c)) && nik != currentNIK(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Operator hanya bisa melihat scorecard sendiri"})
+		return
+	}
+	from, to, ok := scorecardPeriod(c)
+	if !ok {
+		return
+	}
+
+	// Ambil semua operator agar peringkat dalam proses bisa dihitung
+	rows, err := scorecardRows(from, to, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal ambil data scorecard: " + err.Error()})
+		return
+	}
+
+	cards := []OperatorScorecard{}
+	for _, card := range buildScorecards(rows, true) {
+		if card.NIK == nik {
+			cards = append(cards, card)
+		}
+	}
+	if len(cards) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tidak ada data LWP untuk NIK " + nik + " pada periode ini"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nik":  nik,
+		"nama": operator.Nama,
+		"from": from.Format("2006-01-02"),
+		"to":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"data": cards,
+	})
+}
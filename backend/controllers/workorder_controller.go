@@ -3,10 +3,13 @@ package controllers
 import (
 	"factory-api/database"
 	"factory-api/models"
+	"factory-api/realtime"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateWorkOrder - Hanya Admin yang bisa membuat WO baru
@@ -14,6 +17,7 @@ func CreateWorkOrder(c *gin.Context) {
 	var input struct {
 		OrderNumber string `json:"order_number" binding:"required"`
 		PartName    string `json:"part_name" binding:"required"`
+		ItemCode    string `json:"item_code"`
 		Quantity    int    `json:"quantity" binding:"required"`
 	}
 
@@ -22,16 +26,16 @@ func CreateWorkOrder(c *gin.Context) {
 		return
 	}
 
-	// Ambil data admin yang sedang login dari token
-	userID, _ := c.Get("userID")
-	username, _ := c.Get("username")
-
 	wo := models.WorkOrder{
 		OrderNumber: input.OrderNumber,
 		PartName:    input.PartName,
+		ItemCode:    input.ItemCode,
 		Quantity:    input.Quantity,
 		Status:      "PENDING", // Status awal selalu PENDING
-		OperatorID:  uint(userID.(float64)),
+	}
+	// Ambil data admin yang sedang login dari token (user_id berisi NIK)
+	if id, err := strconv.ParseUint(currentNIK(c), 10, 64); err == nil {
+		wo.OperatorID = uint(id)
 	}
 
 	if err := database.DB.Create(&wo).Error; err != nil {
@@ -40,7 +44,7 @@ func CreateWorkOrder(c *gin.Context) {
 	}
 
 	// Catat ke Audit Log
-	database.RecordActivity(uint(userID.(float64)), username.(string), "CREATE_WORK_ORDER", "/admin/work-order")
+	recordAuditChange(c, "CREATE_WORK_ORDER", "work_order", wo.ID, nil, wo, wo.OrderNumber)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Work Order berhasil dibuat", "data": wo})
 }
@@ -71,6 +75,7 @@ func UpdateWOStatus(c *gin.Context) {
 
 	var input struct {
 		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -78,20 +83,38 @@ func UpdateWOStatus(c *gin.Context) {
 		return
 	}
 
-	// Ambil data operator yang melakukan update
-	userID, _ := c.Get("userID")
-	username, _ := c.Get("username")
+	// Ambil data operator yang melakukan update (user_id di token berisi NIK)
+	nik := currentNIK(c)
 
 	// Update Status
+	before := wo
 	oldStatus := wo.Status
 	wo.Status = input.Status
-	wo.OperatorID = uint(userID.(float64)) // Update siapa yang terakhir mengerjakan
+	if id, err := strconv.ParseUint(nik, 10, 64); err == nil {
+		wo.OperatorID = uint(id) // Update siapa yang terakhir mengerjakan
+	}
 
-	database.DB.Save(&wo)
+	// Perubahan status dicatat sebagai revisi (nilai lama tidak hilang)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		rev, err := database.RecordRevision(tx, "work_order", wo.ID, before, wo, nik, input.Reason)
+		if err != nil {
+			return err
+		}
+		if rev != nil {
+			wo.Revision = rev.Revision
+		}
+		return tx.Save(&wo).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal update status Work Order"})
+		return
+	}
 
 	// Catat ke Audit Log (Penting untuk pelacakan)
-	actionDesc := fmt.Sprintf("UPDATE_STATUS: %s -> %s", oldStatus, input.Status)
-	database.RecordActivity(uint(userID.(float64)), username.(string), actionDesc, "/production/work-order/status")
+	recordAuditChange(c, "UPDATE_WO_STATUS", "work_order", wo.ID, before, wo,
+		fmt.Sprintf("%s: %s -> %s", wo.OrderNumber, oldStatus, input.Status))
+
+	publishEvent(realtime.EventWorkOrder, "", gin.H{"id": wo.ID, "order_number": wo.OrderNumber, "from": oldStatus, "to": wo.Status})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Status Work Order diperbarui", 
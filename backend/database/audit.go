@@ -0,0 +1,333 @@
+package database
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"factory-api/models"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Penulisan audit berurutan (satu writer) agar rantai hash tidak bercabang
+var auditMu sync.Mutex
+
+// AuditEvent adalah isi satu baris audit sebelum diberi hash
+type AuditEvent struct {
+	UserID     uint
+	Username   string
+	Role       string
+	IP         string
+	Device     string
+	Method     string
+	Endpoint   string
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{} // Di-marshal ke JSON (nil = kosong)
+	After      interface{}
+	Result     string // Default SUCCESS
+	Details    string
+}
+
+// Helper: Snapshot ke JSON string (string dibiarkan apa adanya)
+func auditPayload(v interface{}) string {
+	switch p := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return p
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
+
+// Algoritma hash audit dengan kunci rahasia (baris lama tanpa HashAlg memakai SHA-256 biasa)
+const AuditHashHMAC = "HMAC-SHA256"
+
+// Helper: Kunci HMAC audit dari env AUDIT_HMAC_KEY (tidak disimpan di database)
+func auditKey() []byte {
+	return []byte(os.Getenv("AUDIT_HMAC_KEY"))
+}
+
+// Helper: File anchor head rantai audit di luar database (env AUDIT_ANCHOR_FILE, default audit_anchor.log)
+func auditAnchorFile() string {
+	if v := os.Getenv("AUDIT_ANCHOR_FILE"); v != "" {
+		return v
+	}
+	return "audit_anchor.log"
+}
+
+// AuditHash menghitung hash baris audit (dipakai saat menulis & memverifikasi)
+func AuditHash(l models.AuditLog) string {
+	content := strings.Join([]string{
+		l.PrevHash,
+		strconv.FormatInt(l.CreatedAt.UnixMicro(), 10),
+		strconv.FormatUint(uint64(l.UserID), 10),
+		l.Username, l.Role, l.IP, l.Device, l.Method, l.Endpoint, l.Action,
+		l.EntityType, l.EntityID, l.Before, l.After, l.Result, l.Details,
+	}, "|")
+	if l.HashAlg == AuditHashHMAC {
+		mac := hmac.New(sha256.New, auditKey())
+		mac.Write([]byte(content))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Helper: Catat head rantai ke file anchor agar penghapusan / penulisan ulang seluruh rantai tetap terdeteksi
+func anchorAuditHead(entry models.AuditLog) {
+	f, err := os.OpenFile(auditAnchorFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Printf("[AUDIT ERROR] Gagal membuka file anchor: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%d %s %s\n", entry.ID, entry.Hash, entry.CreatedAt.Format(time.RFC3339)); err != nil {
+		fmt.Printf("[AUDIT ERROR] Gagal menulis file anchor: %v\n", err)
+	}
+}
+
+// Helper: Baca file anchor (id -> hash); file tidak ada = belum ada anchor
+func readAuditAnchors() (map[uint]string, uint, error) {
+	anchors := map[uint]string{}
+	var maxID uint
+	f, err := os.Open(auditAnchorFile())
+	if os.IsNotExist(err) {
+		return anchors, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		anchors[uint(id)] = fields[1]
+		if uint(id) > maxID {
+			maxID = uint(id)
+		}
+	}
+	return anchors, maxID, scanner.Err()
+}
+
+// WriteAudit menyimpan satu event audit secara sinkron dan menyambungkannya ke rantai hash
+func WriteAudit(ev AuditEvent) (*models.AuditLog, error) {
+	if ev.Result == "" {
+		ev.Result = "SUCCESS"
+	}
+	entry := models.AuditLog{
+		UserID:     ev.UserID,
+		Username:   ev.Username,
+		Role:       ev.Role,
+		IP:         ev.IP,
+		Device:     ev.Device,
+		Method:     ev.Method,
+		Endpoint:   ev.Endpoint,
+		Action:     ev.Action,
+		EntityType: ev.EntityType,
+		EntityID:   ev.EntityID,
+		Before:     auditPayload(ev.Before),
+		After:      auditPayload(ev.After),
+		Result:     ev.Result,
+		Details:    ev.Details,
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var last models.AuditLog
+		if err := tx.Where("hash <> ''").Order("id desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		entry.PrevHash = last.Hash
+		// Presisi mikrodetik agar hash tetap sama setelah dibaca ulang dari database
+		entry.CreatedAt = time.Now().Truncate(time.Microsecond)
+		if len(auditKey()) > 0 {
+			entry.HashAlg = AuditHashHMAC
+		}
+		entry.Hash = AuditHash(entry)
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		fmt.Printf("[AUDIT ERROR] Gagal menyimpan audit %s oleh %s: %v\n", ev.Action, ev.Username, err)
+		return nil, err
+	}
+	anchorAuditHead(entry)
+	return &entry, nil
+}
+
+// Fungsi Helper RecordActivity (Masuk ke SQLite) - versi singkat tanpa konteks request
+func RecordActivity(userID uint, username string, action string, details string) error {
+	fmt.Printf("[AUDIT] User: %s (ID: %d) | Action: %s | Details: %s\n", username, userID, action, details)
+
+	_, err := WriteAudit(AuditEvent{
+		UserID:   userID,
+		Username: username,
+		Action:   action,
+		Details:  details,
+	})
+	return err
+}
+
+// Hasil verifikasi rantai hash audit
+type AuditVerifyResult struct {
+	Checked  int    `json:"checked"`
+	Legacy   int    `json:"legacy"` // Baris lama sebelum rantai hash diaktifkan
+	Valid    bool   `json:"valid"`
+	BrokenID uint   `json:"broken_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// VerifyAuditChain menghitung ulang hash setiap baris, memastikan sambungannya utuh,
+// lalu mencocokkan dengan anchor di luar database (baris terakhir dihapus / seluruh rantai ditulis ulang)
+func VerifyAuditChain() (AuditVerifyResult, error) {
+	result := AuditVerifyResult{Valid: true}
+	prev := ""
+	started := false
+	keyed := false // Setelah baris HMAC pertama, baris berikutnya tidak boleh kembali ke SHA-256 biasa
+	var lastID uint
+
+	anchors, maxAnchor, err := readAuditAnchors()
+	if err != nil {
+		return result, err
+	}
+	if len(auditKey()) == 0 {
+		var count int64
+		DB.Model(&models.AuditLog{}).Where("hash_alg = ?", AuditHashHMAC).Count(&count)
+		if count > 0 {
+			return result, fmt.Errorf("AUDIT_HMAC_KEY belum diset, rantai HMAC tidak bisa diverifikasi")
+		}
+	}
+
+	// Baris yang sudah diarsip: rantai dilanjutkan dari hash terakhir arsip
+	var archive models.AuditArchive
+	if err := DB.Order("to_id desc").Limit(1).Find(&archive).Error; err != nil {
+		return result, err
+	}
+	if archive.ID != 0 && archive.LastHash != "" {
+		if h, ok := anchors[archive.ToID]; ok && h != archive.LastHash {
+			result.Valid = false
+			result.BrokenID = archive.ToID
+			result.Reason = "last_hash arsip tidak cocok dengan anchor"
+			return result, nil
+		}
+		prev = archive.LastHash
+		started = true
+		lastID = archive.ToID
+	}
+
+	var batch []models.AuditLog
+	err = DB.Order("id asc").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, l := range batch {
+			if !started && l.Hash == "" {
+				result.Legacy++
+				continue
+			}
+			started = true
+			result.Checked++
+
+			switch {
+			case l.PrevHash != prev:
+				result.Reason = "prev_hash tidak cocok dengan baris sebelumnya (baris dihapus/disisipkan)"
+			case keyed && l.HashAlg != AuditHashHMAC:
+				result.Reason = "baris tanpa HMAC setelah rantai HMAC dimulai (hash diturunkan)"
+			case AuditHash(l) != l.Hash:
+				result.Reason = "isi baris tidak cocok dengan hash (baris diubah)"
+			case anchors[l.ID] != "" && anchors[l.ID] != l.Hash:
+				result.Reason = "hash baris tidak cocok dengan anchor (rantai ditulis ulang)"
+			default:
+				prev = l.Hash
+				keyed = keyed || l.HashAlg == AuditHashHMAC
+				lastID = l.ID
+				continue
+			}
+			result.Valid = false
+			result.BrokenID = l.ID
+			return fmt.Errorf("stop")
+		}
+		return nil
+	}).Error
+	if err != nil && result.Valid {
+		return result, err
+	}
+	if result.Valid && maxAnchor > lastID {
+		result.Valid = false
+		result.BrokenID = maxAnchor
+		result.Reason = "baris terakhir hilang (anchor mencatat head yang tidak ada di database)"
+	}
+	return result, nil
+}
+
+// AuditFilter adalah kriteria pencarian audit log
+type AuditFilter struct {
+	Username   string
+	Action     string
+	Endpoint   string
+	EntityType string
+	EntityID   string
+	Result     string
+	From       *time.Time
+	To         *time.Time // Eksklusif
+	Search     string     // Cari di action, details, before & after
+}
+
+// Helper: Escape % dan _ pada input user agar dicari sebagai karakter biasa di LIKE (dipakai dengan ESCAPE '\')
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Apply menerapkan filter ke query audit_logs
+func (f AuditFilter) Apply(query *gorm.DB) *gorm.DB {
+	if f.Username != "" {
+		query = query.Where("username = ?", f.Username)
+	}
+	if f.Action != "" {
+		query = query.Where(`action LIKE ? ESCAPE '\'`, escapeLike(f.Action)+"%")
+	}
+	if f.Endpoint != "" {
+		query = query.Where(`endpoint LIKE ? ESCAPE '\'`, escapeLike(f.Endpoint)+"%")
+	}
+	if f.EntityType != "" {
+		query = query.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != "" {
+		query = query.Where("entity_id = ?", f.EntityID)
+	}
+	if f.Result != "" {
+		query = query.Where("result = ?", f.Result)
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("created_at < ?", *f.To)
+	}
+	if f.Search != "" {
+		like := "%" + escapeLike(f.Search) + "%"
+		query = query.Where("(action LIKE ? ESCAPE '\\' OR details LIKE ? ESCAPE '\\' OR `before` LIKE ? ESCAPE '\\' OR `after` LIKE ? ESCAPE '\\')",
+			like, like, like, like)
+	}
+	return query
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"factory-api/models"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveAuditLogs memindahkan audit log sebelum cutoff ke file .jsonl.gz lalu menghapusnya dari database.
+// Hash baris terakhir disimpan di AuditArchive agar rantai hash sisa baris tetap bisa diverifikasi.
+func ArchiveAuditLogs(cutoff time.Time, dir, createdBy string) (*models.AuditArchive, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	var logs []models.AuditLog
+	if err := DB.Where("created_at < ?", cutoff).Order("id asc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("gagal membuat folder arsip: %w", err)
+	}
+	first, last := logs[0], logs[len(logs)-1]
+	name := fmt.Sprintf("audit-%06d-%06d-%s.jsonl.gz", first.ID, last.ID, time.Now().Format("20060102150405"))
+	path := filepath.Join(dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat file arsip: %w", err)
+	}
+	sum := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, sum))
+	enc := json.NewEncoder(gz)
+	for _, l := range logs {
+		if err := enc.Encode(l); err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	archive := models.AuditArchive{
+		FileName:   name,
+		FromID:     first.ID,
+		ToID:       last.ID,
+		Rows:       len(logs),
+		FromDate:   first.CreatedAt,
+		ToDate:     last.CreatedAt,
+		LastHash:   last.Hash,
+		FileSHA256: hex.EncodeToString(sum.Sum(nil)),
+		CreatedBy:  createdBy,
+	}
+
+	// File sudah aman di disk, baru hapus dari database
+	tx := DB.Begin()
+	if err := tx.Create(&archive).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Where("id BETWEEN ? AND ?", first.ID, last.ID).Delete(&models.AuditLog{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
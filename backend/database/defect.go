@@ -0,0 +1,52 @@
+package database
+
+import (
+	"factory-api/models"
+	"factory-api/process"
+	"log"
+	"strings"
+)
+
+// Tingkat keparahan reject yang dikenal
+var DefectSeverities = []string{"MINOR", "MAJOR", "CRITICAL"}
+
+// SeedDefectTypes mengisi katalog reject dari definisi proses (jenis bawaan per kolom sumber data).
+// Jenis yang sudah ada tidak diubah, sehingga label / severity / aktif hasil edit admin tetap.
+func SeedDefectTypes() {
+	created := 0
+	for _, def := range process.All() {
+		for i, d := range def.Defects {
+			var count int64
+			DB.Unscoped().Model(&models.DefectType{}).Where("proses = ? AND code = ?", def.Code, d.Code).Count(&count)
+			if count > 0 {
+				continue
+			}
+			if err := DB.Create(&models.DefectType{
+				Proses:   def.Code,
+				Code:     d.Code,
+				Label:    d.Label,
+				Column:   d.Column,
+				Severity: "MINOR",
+				Urutan:   (i + 1) * 10,
+				Aktif:    true,
+			}).Error; err == nil {
+				created++
+			}
+		}
+	}
+	if created > 0 {
+		log.Printf("Seeder: %d jenis reject ditambahkan ke katalog", created)
+	}
+}
+
+// DefectTypes mengambil katalog reject satu proses (urut tampil).
+// activeOnly = hanya yang aktif (untuk input LWP); laporan memakai semua jenis agar data lama tetap terbaca.
+func DefectTypes(proses string, activeOnly bool) []models.DefectType {
+	var defects []models.DefectType
+	query := DB.Where("proses = ?", strings.ToUpper(proses))
+	if activeOnly {
+		query = query.Where("aktif = ?", true)
+	}
+	query.Order("urutan asc, id asc").Find(&defects)
+	return defects
+}
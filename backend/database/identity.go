@@ -0,0 +1,111 @@
+package database
+
+import (
+	"errors"
+	"factory-api/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOperatorNotFound  = errors.New("operator tidak ditemukan di tm_employ")
+	ErrOperatorAmbiguous = errors.New("nama operator tidak unik, gunakan NIK")
+)
+
+// Lama cache hasil resolusi identitas
+const identityCacheTTL = 10 * time.Minute
+
+type identityCacheEntry struct {
+	identity models.OperatorIdentity
+	expires  time.Time
+}
+
+var (
+	identityMu    sync.Mutex
+	identityCache = map[string]identityCacheEntry{}
+)
+
+// InvalidateOperatorCache dipanggil setelah alias berubah
+func InvalidateOperatorCache() {
+	identityMu.Lock()
+	identityCache = map[string]identityCacheEntry{}
+	identityMu.Unlock()
+}
+
+// Helper: Lengkapi identitas dengan nama dari tm_employ & NPK alias
+func completeIdentity(nik string) (models.OperatorIdentity, error) {
+	identity := models.OperatorIdentity{NIK: nik, Nama: nik, NPKs: []string{nik}}
+	if MySQL != nil {
+		var emp models.TmEmploy
+		if err := MySQL.Where("nik = ?", nik).First(&emp).Error; err != nil {
+			return identity, ErrOperatorNotFound
+		}
+		identity.Nama = emp.Nama
+		identity.Verified = true
+	}
+
+	var aliases []models.OperatorAlias
+	DB.Where("nik = ? AND kind = ?", nik, "NPK").Find(&aliases)
+	for _, a := range aliases {
+		if a.Alias != nik {
+			identity.NPKs = append(identity.NPKs, a.Alias)
+		}
+	}
+	return identity, nil
+}
+
+// ResolveOperator memetakan NIK, NPK (alias) atau nama operator ke satu identitas (NIK).
+// Urutan: alias lokal -> NIK di tm_employ -> nama di tm_employ (harus unik).
+func ResolveOperator(key string) (models.OperatorIdentity, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return models.OperatorIdentity{}, ErrOperatorNotFound
+	}
+
+	identityMu.Lock()
+	entry, ok := identityCache[key]
+	identityMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.identity, nil
+	}
+
+	identity, err := resolveOperator(key)
+	if err != nil {
+		return identity, err
+	}
+
+	identityMu.Lock()
+	identityCache[key] = identityCacheEntry{identity: identity, expires: time.Now().Add(identityCacheTTL)}
+	identityMu.Unlock()
+	return identity, nil
+}
+
+func resolveOperator(key string) (models.OperatorIdentity, error) {
+	var alias models.OperatorAlias
+	if err := DB.Where("alias = ?", key).First(&alias).Error; err == nil {
+		return completeIdentity(alias.NIK)
+	}
+
+	// Tanpa MySQL tidak bisa verifikasi, anggap key adalah NIK
+	if MySQL == nil {
+		return completeIdentity(key)
+	}
+
+	var count int64
+	MySQL.Model(&models.TmEmploy{}).Where("nik = ?", key).Count(&count)
+	if count > 0 {
+		return completeIdentity(key)
+	}
+
+	var emps []models.TmEmploy
+	MySQL.Where("UPPER(TRIM(nama)) = ?", strings.ToUpper(key)).Limit(2).Find(&emps)
+	switch len(emps) {
+	case 0:
+		return models.OperatorIdentity{NIK: key}, ErrOperatorNotFound
+	case 1:
+		return completeIdentity(emps[0].NIK)
+	default:
+		return models.OperatorIdentity{NIK: key}, ErrOperatorAmbiguous
+	}
+}
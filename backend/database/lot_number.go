@@ -0,0 +1,171 @@
+package database
+
+import (
+	"errors"
+	"factory-api/models"
+	"factory-api/process"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Format default jika proses belum punya format sendiri
+const DefaultLotPattern = "{PROC}{MC}-{YYMMDD}-{SEQ}"
+
+// SQLite hanya punya satu writer, mutex ini mencegah "database is locked" saat request bersamaan
+var lotNumberMu sync.Mutex
+
+var seqToken = regexp.MustCompile(`\{SEQ(?::(\d+))?\}`)
+
+// ErrLotSource: proses / mesin untuk nomor lot tidak terdaftar (kesalahan input, bukan error server)
+var ErrLotSource = errors.New("sumber nomor lot tidak valid")
+
+// Helper: Proses harus ada di registry proses dan mesin terdaftar & aktif (keduanya masuk ke kunci counter)
+func validateLotSource(tx *gorm.DB, proses, noMC string) error {
+	if _, ok := process.Get(proses); !ok {
+		return fmt.Errorf("%w: proses %s tidak terdaftar", ErrLotSource, proses)
+	}
+	var count int64
+	if err := tx.Model(&models.Machine{}).Where("no_mc = ? AND aktif = ?", noMC, true).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: mesin %s tidak terdaftar / tidak aktif", ErrLotSource, noMC)
+	}
+	return nil
+}
+
+// ValidateLotPattern memastikan pattern punya token {SEQ} agar nomor pasti unik
+func ValidateLotPattern(pattern string) error {
+	if !seqToken.MatchString(pattern) {
+		return errors.New("pattern wajib mengandung {SEQ}")
+	}
+	return nil
+}
+
+// lotSequenceKey menentukan kunci counter {SEQ} dari token yang benar-benar ada di pattern,
+// agar nomor tidak bentrok walau pattern tidak memuat {MC} / tanggal (counter tidak di-reset per mesin / hari).
+// Pattern yang memuat {PROC}, {MC} dan tanggal tetap memakai kunci lama "proses|mesin|tanggal".
+func lotSequenceKey(pattern, proses, noMC string, at time.Time) string {
+	hasProc := strings.Contains(pattern, "{PROC}")
+	hasMC := strings.Contains(pattern, "{MC}")
+	hasDate := strings.Contains(pattern, "{YYYYMMDD}") || strings.Contains(pattern, "{YYMMDD}")
+	if hasProc && hasMC && hasDate {
+		return proses + "|" + noMC + "|" + at.Format("20060102")
+	}
+
+	// Kunci diawali pattern: proses lain dengan pattern sama tanpa {PROC} ikut berbagi counter
+	parts := []string{pattern}
+	if hasProc {
+		parts = append(parts, proses)
+	}
+	if hasMC {
+		parts = append(parts, noMC)
+	}
+	if hasDate {
+		parts = append(parts, at.Format("20060102"))
+	}
+	return strings.Join(parts, "|")
+}
+
+// FormatLotNumber mengisi token pada pattern
+func FormatLotNumber(pattern, proses, noMC string, at time.Time, seq int) string {
+	out := strings.NewReplacer(
+		"{PROC}", proses,
+		"{MC}", noMC,
+		"{YYYYMMDD}", at.Format("20060102"),
+		"{YYMMDD}", at.Format("060102"),
+	).Replace(pattern)
+
+	return seqToken.ReplaceAllStringFunc(out, func(tok string) string {
+		width := 4
+		if m := seqToken.FindStringSubmatch(tok); m[1] != "" {
+			width, _ = strconv.Atoi(m[1])
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+}
+
+// NextLotNumbers mengeluarkan n nomor lot baru (urutan per token pattern, lihat lotSequenceKey)
+// dan langsung mencatatnya sebagai reservasi, semua dalam satu transaksi.
+func NextLotNumbers(proses, noMC string, n int, workOrderID uint, reservedBy string) ([]models.LotReservation, error) {
+	if n <= 0 {
+		n = 1
+	}
+
+	lotNumberMu.Lock()
+	defer lotNumberMu.Unlock()
+
+	now := time.Now()
+	var reservations []models.LotReservation
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateLotSource(tx, proses, noMC); err != nil {
+			return err
+		}
+
+		pattern := DefaultLotPattern
+		var format models.LotNumberFormat
+		if err := tx.Where("proses = ?", proses).First(&format).Error; err == nil {
+			pattern = format.Pattern
+		}
+
+		seq := models.LotSequence{SeqKey: lotSequenceKey(pattern, proses, noMC, now)}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.LotSequence{}).Where("seq_key = ?", seq.SeqKey).
+			Update("last_seq", gorm.Expr("last_seq + ?", n)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seq_key = ?", seq.SeqKey).First(&seq).Error; err != nil {
+			return err
+		}
+
+		for i := seq.LastSeq - n + 1; i <= seq.LastSeq; i++ {
+			r := models.LotReservation{
+				LotNo:       FormatLotNumber(pattern, proses, noMC, now, i),
+				Proses:      proses,
+				NoMC:        noMC,
+				WorkOrderID: workOrderID,
+				Status:      "RESERVED",
+				ReservedBy:  reservedBy,
+			}
+			// Constraint unique di lot_no jadi pengaman terakhir jika pattern admin tidak unik
+			if err := tx.Create(&r).Error; err != nil {
+				return fmt.Errorf("nomor lot %s bentrok: %w", r.LotNo, err)
+			}
+			reservations = append(reservations, r)
+		}
+		return nil
+	})
+
+	return reservations, err
+}
+
+// VoidLotNumber membatalkan nomor lot yang tidak jadi dipakai (misal diganti lewat koreksi LWP)
+func VoidLotNumber(tx *gorm.DB, lotNo string) error {
+	return tx.Model(&models.LotReservation{}).
+		Where("lot_no = ? AND status IN ?", lotNo, []string{"RESERVED", "USED"}).
+		Update("status", "VOID").Error
+}
+
+// UseLotNumber menandai nomor lot hasil reservasi sebagai sudah dipakai
+func UseLotNumber(tx *gorm.DB, lotNo string) error {
+	res := tx.Model(&models.LotReservation{}).
+		Where("lot_no = ? AND status = ?", lotNo, "RESERVED").
+		Update("status", "USED")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("nomor lot %s belum dipesan atau sudah dipakai", lotNo)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"errors"
+	"factory-api/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Helper: Database SQLite in-memory untuk satu test
+func setupTestDB(t *testing.T, tables ...interface{}) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gagal buka sqlite: %v", err)
+	}
+	// Satu koneksi saja agar semua query melihat database in-memory yang sama
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("gagal migrasi: %v", err)
+	}
+	prev := DB
+	DB = db
+	t.Cleanup(func() { DB = prev })
+}
+
+// Helper: Tabel layanan nomor lot + satu mesin pressing aktif
+func setupLotNumberDB(t *testing.T) {
+	t.Helper()
+	setupTestDB(t, &models.Machine{}, &models.LotNumberFormat{}, &models.LotSequence{}, &models.LotReservation{})
+	if err := DB.Create(&models.Machine{NoMC: "04A", Proses: "PRS", Aktif: true}).Error; err != nil {
+		t.Fatalf("gagal membuat mesin: %v", err)
+	}
+}
+
+func TestLotSequenceKey(t *testing.T) {
+	at := time.Date(2026, 2, 1, 8, 0, 0, 0, time.Local)
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{DefaultLotPattern, "PRS|04A|20260201"},
+		{"{MC}-{SEQ}", "{MC}-{SEQ}|04A"},
+		{"{PROC}-{YYMMDD}-{SEQ:5}", "{PROC}-{YYMMDD}-{SEQ:5}|PRS|20260201"},
+		{"LOT-{SEQ}", "LOT-{SEQ}"},
+	}
+	for _, tt := range tests {
+		if got := lotSequenceKey(tt.pattern, "PRS", "04A", at); got != tt.want {
+			t.Errorf("lotSequenceKey(%q) = %q, seharusnya %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLotNumber(t *testing.T) {
+	at := time.Date(2026, 2, 1, 8, 0, 0, 0, time.Local)
+	tests := []struct {
+		pattern string
+		seq     int
+		want    string
+	}{
+		{DefaultLotPattern, 7, "PRS04A-260201-0007"},
+		{"{PROC}-{YYYYMMDD}-{SEQ:6}", 12, "PRS-20260201-000012"},
+		{"{MC}{SEQ:2}", 123, "04A123"},
+	}
+	for _, tt := range tests {
+		if got := FormatLotNumber(tt.pattern, "PRS", "04A", at, tt.seq); got != tt.want {
+			t.Errorf("FormatLotNumber(%q, %d) = %q, seharusnya %q", tt.pattern, tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestNextLotNumbersRejectsUnknownSource(t *testing.T) {
+	setupLotNumberDB(t)
+	DB.Create(&models.Machine{NoMC: "09Z", Proses: "PRS"})
+	DB.Model(&models.Machine{}).Where("no_mc = ?", "09Z").Update("aktif", false)
+
+	tests := []struct {
+		name, proses, noMC string
+	}{
+		{"proses tidak terdaftar", "XYZ", "04A"},
+		{"mesin tidak terdaftar", "PRS", "99X"},
+		{"mesin tidak aktif", "PRS", "09Z"},
+		{"mesin berisi pemisah kunci", "PRS", "04A|20260201"},
+	}
+	for _, tt := range tests {
+		if _, err := NextLotNumbers(tt.proses, tt.noMC, 1, 0, "tester"); !errors.Is(err, ErrLotSource) {
+			t.Errorf("%s: error = %v, seharusnya ErrLotSource", tt.name, err)
+		}
+	}
+
+	var count int64
+	DB.Model(&models.LotSequence{}).Count(&count)
+	if count != 0 {
+		t.Errorf("counter dibuat untuk sumber tidak valid (%d baris)", count)
+	}
+}
+
+func TestNextLotNumbersConcurrentAllocation(t *testing.T) {
+	setupLotNumberDB(t)
+
+	const workers, perRequest = 20, 3
+	var wg sync.WaitGroup
+	results := make(chan []models.LotReservation, workers)
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reserved, err := NextLotNumbers("PRS", "04A", perRequest, 0, "tester")
+			if err != nil {
+				errs <- err
+				return
+			}
+			results <- reserved
+		}()
+	}
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("NextLotNumbers error: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for reserved := range results {
+		if len(reserved) != perRequest {
+			t.Fatalf("dapat %d nomor, seharusnya %d", len(reserved), perRequest)
+		}
+		for _, r := range reserved {
+			if seen[r.LotNo] {
+				t.Fatalf("nomor lot %s keluar dua kali", r.LotNo)
+			}
+			seen[r.LotNo] = true
+		}
+	}
+	if len(seen) != workers*perRequest {
+		t.Errorf("dapat %d nomor unik, seharusnya %d", len(seen), workers*perRequest)
+	}
+
+	var seq models.LotSequence
+	DB.First(&seq)
+	if seq.LastSeq != workers*perRequest {
+		t.Errorf("last_seq = %d, seharusnya %d (tidak ada nomor yang terlewat)", seq.LastSeq, workers*perRequest)
+	}
+}
+
+func TestUseLotNumberOnlyOnce(t *testing.T) {
+	setupLotNumberDB(t)
+	reserved, err := NextLotNumbers("PRS", "04A", 1, 0, "tester")
+	if err != nil {
+		t.Fatalf("NextLotNumbers error: %v", err)
+	}
+	lotNo := reserved[0].LotNo
+
+	if err := UseLotNumber(DB, lotNo); err != nil {
+		t.Fatalf("pemakaian pertama gagal: %v", err)
+	}
+	if err := UseLotNumber(DB, lotNo); err == nil {
+		t.Error("nomor lot yang sudah USED tidak boleh dipakai lagi")
+	}
+	if err := UseLotNumber(DB, "TIDAK-ADA"); err == nil {
+		t.Error("nomor lot yang tidak dipesan tidak boleh dipakai")
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"encoding/json"
+	"factory-api/models"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Field yang tidak dianggap sebagai koreksi data
+var revisionIgnoredFields = map[string]bool{
+	"ID": true, "id": true, "CreatedAt": true, "created_at": true,
+	"UpdatedAt": true, "updated_at": true, "DeletedAt": true, "deleted_at": true,
+	"revision": true, "Revision": true,
+}
+
+// Helper: Ubah struct menjadi map lewat JSON (nama field mengikuti tag json)
+func toFieldMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+// DiffFields membandingkan dua snapshot dan mengembalikan field yang berubah (urut nama field)
+func DiffFields(before, after interface{}) ([]models.FieldChange, error) {
+	b, err := toFieldMap(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFieldMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.FieldChange{}
+	for field, newVal := range a {
+		if revisionIgnoredFields[field] {
+			continue
+		}
+		if oldVal := b[field]; !reflect.DeepEqual(oldVal, newVal) {
+			changes = append(changes, models.FieldChange{Field: field, Before: oldVal, After: newVal})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// RecordRevision mencatat koreksi satu record di dalam transaksi yang sama dengan update-nya.
+// Mengembalikan nil jika tidak ada field yang berubah.
+func RecordRevision(tx *gorm.DB, entityType string, entityID uint, before, after interface{},
+	changedBy, reason string, extra ...models.FieldChange) (*models.Revision, error) {
+
+	changes, err := DiffFields(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membandingkan data: %w", err)
+	}
+	changes = append(changes, extra...)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var last int
+	tx.Model(&models.Revision{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Select("COALESCE(MAX(revision), 1)").Scan(&last)
+
+	rev := models.Revision{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Revision:   last + 1,
+		ChangedBy:  changedBy,
+		Reason:     reason,
+		Changes:    changes,
+	}
+	if err := tx.Create(&rev).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -5,6 +5,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"gorm.io/gorm"
+	"time"
+
+	"github.com/robfig/cron/v3"
 )
 
 func SeedUsers() {
@@ -58,4 +61,31 @@ func SeedUsers() {
 			log.Printf("Seeder: User %s already exists, skipping...\n", u.Username)
 		}
 	}
-}
\ No newline at end of file
+}
+// Jadwal laporan bawaan: ringkasan produksi harian ke MANAGER tiap pagi
+func SeedReportSchedules() {
+	var count int64
+	DB.Model(&models.ReportSchedule{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	spec := "30 6 * * *"
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return
+	}
+	next := sched.Next(time.Now())
+
+	DB.Create(&models.ReportSchedule{
+		Name:      "Ringkasan Produksi Harian",
+		Kind:      "DAILY_SUMMARY",
+		Cron:      spec,
+		Format:    "xlsx",
+		Roles:     []string{"MANAGER"},
+		Aktif:     true,
+		CreatedBy: "SYSTEM",
+		NextRunAt: &next,
+	})
+	log.Println("Seeder: Jadwal laporan harian dibuat")
+}
@@ -31,7 +31,18 @@ func ConnectDatabase() {
 	}
 
 	// Migrasi tabel aplikasi
-	sqliteDB.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.PerCycle{})
+	sqliteDB.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.AuditArchive{}, &models.PerCycle{},
+		&models.WorkOrder{}, &models.Machine{}, &models.Schedule{},
+		&models.Lot{}, &models.LotLink{},
+		&models.LotNumberFormat{}, &models.LotSequence{}, &models.LotReservation{},
+		&models.ScanEvent{}, &models.Mold{}, &models.MoldMaintenance{},
+		&models.MoldChange{}, &models.PMPlan{}, &models.PMTask{},
+		&models.RepairTicket{}, &models.AlertRule{}, &models.Alert{},
+		&models.NotificationTemplate{}, &models.NotificationPreference{}, &models.NotificationJob{},
+		&models.LWPReport{}, &models.Revision{},
+		&models.ReportSchedule{}, &models.ReportRun{}, &models.OperatorAlias{}, &models.LeaderProcess{},
+		&models.StandardOverride{}, &models.DefectType{}, &models.LWPDefect{},
+		&models.NGDisposition{}, &models.MaterialCost{})
 	DB = sqliteDB
 	fmt.Println("✅ SQLite Connected (besq.db) - Pure Go Mode")
 
@@ -60,18 +71,3 @@ func ConnectDatabase() {
 		fmt.Println("✅ MySQL Connected (Data Statistik Siap)")
 	}
 }
-
-// Fungsi Helper RecordActivity (Masuk ke SQLite)
-func RecordActivity(userID uint, username string, action string, details string) {
-	fmt.Printf("[AUDIT] User: %s (ID: %d) | Action: %s | Details: %s\n", username, userID, action, details)
-
-	audit := models.AuditLog{
-		Username: username, 
-		Action:   action + " - " + details,
-	}
-	
-	go func() {
-		// Simpan log ke SQLite
-		DB.Create(&audit)
-	}()
-}
\ No newline at end of file
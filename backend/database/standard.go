@@ -0,0 +1,231 @@
+package database
+
+import (
+	"factory-api/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tanggal berlaku snapshot ERP pertama (nilai v_stdlot sebelum dicatat dianggap sama)
+const StandardBaselineDate = "2000-01-01"
+
+// Tanggal akhir untuk versi yang masih berlaku
+const standardOpenEnd = "9999-12-31"
+
+// RechainStandardOverrides mengisi ulang EffectiveTo semua versi satu rantai (item + mold + mesin + source):
+// versi berlaku sampai sehari sebelum versi berikutnya, versi terakhir terbuka (kosong)
+func RechainStandardOverrides(tx *gorm.DB, key models.StandardOverride) error {
+	var versions []models.StandardOverride
+	if err := tx.Where("item_code = ? AND mold_code = ? AND no_mc = ? AND source = ?",
+		key.ItemCode, key.MoldCode, key.NoMC, key.Source).
+		Order("effective_from asc").Find(&versions).Error; err != nil {
+		return err
+	}
+
+	for i := range versions {
+		to := ""
+		if i+1 < len(versions) {
+			to = shiftDate(versions[i+1].EffectiveFrom, -1)
+		}
+		if versions[i].EffectiveTo != to {
+			if err := tx.Model(&versions[i]).Update("effective_to", to).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Helper: Geser tanggal YYYY-MM-DD sejumlah hari
+func shiftDate(date string, days int) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
+
+// Helper: Akhir efektif versi (kosong = terbuka)
+func effectiveEnd(o models.StandardOverride) string {
+	if o.EffectiveTo == "" {
+		return standardOpenEnd
+	}
+	return o.EffectiveTo
+}
+
+// Helper: Query versi yang overlap dengan rentang tanggal (inklusif)
+func overlapping(from, to string) *gorm.DB {
+	return DB.Where("effective_from <= ? AND (effective_to = '' OR effective_to >= ?)", to, from)
+}
+
+// StandardOverridesBetween mengambil versi MANUAL yang berlaku di rentang tanggal (inklusif)
+func StandardOverridesBetween(from, to string) []models.StandardOverride {
+	var overrides []models.StandardOverride
+	overlapping(from, to).Where("source = ?", "MANUAL").Find(&overrides)
+	return overrides
+}
+
+// StandardOn mengambil versi standar yang berlaku untuk item + mold (+ mesin) pada satu tanggal.
+// nil = belum ada versi, pakai nilai v_stdlot saat ini.
+func StandardOn(itemCode, moldCode, noMC, tanggal string) *models.StandardOverride {
+	find := func(noMC, source string) *models.StandardOverride {
+		var versions []models.StandardOverride
+		overlapping(tanggal, tanggal).
+			Where("item_code = ? AND mold_code = ? AND no_mc = ? AND source = ?", itemCode, moldCode, noMC, source).
+			Limit(1).Find(&versions)
+		if len(versions) == 0 {
+			return nil
+		}
+		return &versions[0]
+	}
+
+	if noMC != "" {
+		if o := find(noMC, "MANUAL"); o != nil && o.TgtQtyPJam > 0 {
+			return o
+		}
+	}
+	if o := find("", "MANUAL"); o != nil && o.TgtQtyPJam > 0 {
+		return o
+	}
+	return find("", "ERP")
+}
+
+// ItemStandardOn mengambil target per jam item tanpa mold pada satu tanggal (MAX semua mold, seperti v_stdlot):
+// versi MANUAL semua mesin dulu, lalu snapshot ERP yang berlaku saat itu. 0 = belum ada versi, pakai v_stdlot saat ini.
+func ItemStandardOn(itemCode, tanggal string) float64 {
+	for _, source := range []string{"MANUAL", "ERP"} {
+		var tgt float64
+		overlapping(tanggal, tanggal).Model(&models.StandardOverride{}).
+			Where("item_code = ? AND no_mc = '' AND source = ? AND tgt_qty_p_jam > 0", itemCode, source).
+			Select("COALESCE(MAX(tgt_qty_p_jam), 0)").Scan(&tgt)
+		if tgt > 0 {
+			return tgt
+		}
+	}
+	return 0
+}
+
+// StandardSegments menyusun segmen standar non-overlap untuk rentang tanggal, siap di-join ke MySQL:
+//   - segmen mesin: versi MANUAL per mesin (target > 0)
+//   - segmen umum: versi MANUAL semua mesin (target > 0), lalu snapshot ERP lama yang tidak tertutup MANUAL
+//
+// Snapshot ERP yang masih berlaku tidak dikirim karena nilainya sama dengan v_stdlot saat ini.
+func StandardSegments(from, to string) (machine, general []models.StandardSegment) {
+	var versions []models.StandardOverride
+	overlapping(from, to).Order("effective_from asc").Find(&versions)
+
+	type chainKey struct{ Item, Mold string }
+	manual := map[chainKey][][2]string{}
+	erp := []models.StandardOverride{}
+
+	for _, v := range versions {
+		seg := models.StandardSegment{
+			ItemCode: v.ItemCode, MoldCode: v.MoldCode, NoMC: v.NoMC,
+			TgtQtyPJam: v.TgtQtyPJam, From: v.EffectiveFrom, To: effectiveEnd(v),
+		}
+		switch {
+		case v.Source == "ERP":
+			if v.EffectiveTo != "" {
+				erp = append(erp, v)
+			}
+		case v.TgtQtyPJam <= 0:
+			// Penanda "kembali ke level di bawahnya", tidak menghasilkan segmen
+		case v.NoMC != "":
+			machine = append(machine, seg)
+		default:
+			general = append(general, seg)
+			key := chainKey{v.ItemCode, v.MoldCode}
+			manual[key] = append(manual[key], [2]string{seg.From, seg.To})
+		}
+	}
+
+	// MANUAL menutup ERP: potong interval snapshot ERP dengan interval MANUAL
+	for _, v := range erp {
+		pieces := [][2]string{{v.EffectiveFrom, v.EffectiveTo}}
+		covers := manual[chainKey{v.ItemCode, v.MoldCode}]
+		sort.Slice(covers, func(i, j int) bool { return covers[i][0] < covers[j][0] })
+		for _, cover := range covers {
+			next := [][2]string{}
+			for _, p := range pieces {
+				if cover[1] < p[0] || cover[0] > p[1] {
+					next = append(next, p)
+					continue
+				}
+				if cover[0] > p[0] {
+					next = append(next, [2]string{p[0], shiftDate(cover[0], -1)})
+				}
+				if cover[1] < p[1] {
+					next = append(next, [2]string{shiftDate(cover[1], 1), p[1]})
+				}
+			}
+			pieces = next
+		}
+		for _, p := range pieces {
+			general = append(general, models.StandardSegment{
+				ItemCode: v.ItemCode, MoldCode: v.MoldCode, TgtQtyPJam: v.TgtQtyPJam, From: p[0], To: p[1],
+			})
+		}
+	}
+	return machine, general
+}
+
+// Nilai standar v_stdlot saat ini (MAX per item + mold)
+type ERPStandard struct {
+	ItemCode   string
+	MoldCode   string
+	TgtQtyPJam float64
+}
+
+// SnapshotERPStandards mencatat versi ERP baru untuk setiap item + mold yang nilainya berubah.
+// Snapshot pertama berlaku sejak StandardBaselineDate. Mengembalikan jumlah versi baru.
+func SnapshotERPStandards(current []ERPStandard, tanggal string) (int, error) {
+	var open []models.StandardOverride
+	DB.Where("source = ? AND effective_to = ''", "ERP").Find(&open)
+	latest := map[[2]string]models.StandardOverride{}
+	for _, o := range open {
+		latest[[2]string{o.ItemCode, o.MoldCode}] = o
+	}
+
+	created := 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, s := range current {
+			if s.ItemCode == "" || s.TgtQtyPJam <= 0 {
+				continue
+			}
+			prev, ok := latest[[2]string{s.ItemCode, s.MoldCode}]
+			if ok && prev.TgtQtyPJam == s.TgtQtyPJam {
+				continue
+			}
+
+			version := models.StandardOverride{
+				ItemCode:      s.ItemCode,
+				MoldCode:      s.MoldCode,
+				Source:        "ERP",
+				TgtQtyPJam:    s.TgtQtyPJam,
+				EffectiveFrom: StandardBaselineDate,
+				Reason:        "Snapshot awal v_stdlot",
+				CreatedBy:     "SYSTEM",
+			}
+			if ok {
+				version.EffectiveFrom = tanggal
+				version.Reason = "Perubahan v_stdlot"
+				// Perubahan kedua di hari yang sama: ganti versi hari ini
+				if prev.EffectiveFrom == tanggal {
+					version.ID = prev.ID
+					version.CreatedAt = prev.CreatedAt
+				}
+			}
+			if err := tx.Save(&version).Error; err != nil {
+				return err
+			}
+			if err := RechainStandardOverrides(tx, version); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
@@ -4,6 +4,10 @@ import (
 	"factory-api/controllers"
 	"factory-api/database"
 	"factory-api/middleware"
+	"factory-api/notify"
+	"factory-api/process"
+	"log"
+	"os"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/gin-contrib/cors"
@@ -22,9 +26,38 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Definisi proses tambahan (CUT, FIN, ...) dari file JSON; PRS sudah bawaan (contoh: processes.example.json)
+	processConfig := os.Getenv("PROCESS_CONFIG")
+	if processConfig == "" {
+		processConfig = "processes.json"
+	}
+	if err := process.Load(processConfig); err != nil {
+		log.Printf("Gagal membaca konfigurasi proses: %v", err)
+	}
+
 	// 1. Inisialisasi Database & Seeder
 	database.ConnectDatabase()
 	database.SeedUsers()
+	database.SeedReportSchedules()
+	database.SeedDefectTypes()
+	if os.Getenv("AUDIT_HMAC_KEY") == "" {
+		log.Printf("AUDIT_HMAC_KEY belum diset, rantai audit memakai SHA-256 tanpa kunci")
+	}
+
+	// Alert engine andon (evaluasi rule tiap menit)
+	go controllers.StartAlertEngine(time.Minute)
+
+	// Worker antrian notifikasi (email / webhook / chat)
+	go notify.StartWorker(30 * time.Second)
+
+	// Retention audit log: arsip baris lama ke file terkompresi (cek tiap 24 jam)
+	go controllers.StartAuditRetention(24 * time.Hour)
+
+	// Snapshot v_stdlot (versi standar ERP) agar histori target tidak berubah saat standar diperbarui
+	go controllers.StartStandardSnapshot(6 * time.Hour)
+
+	// Laporan terjadwal (cek jadwal cron tiap menit)
+	go controllers.StartReportScheduler(time.Minute)
 	
 	// 2. Route Public (Tanpa Token)
 	r.POST("/login", controllers.Login)
@@ -40,7 +73,39 @@ admin := r.Group("/admin")
 		admin.DELETE("/users/:id", controllers.DeleteUser)      // Delete
 		
 		admin.GET("/audit-logs", controllers.GetAuditLogs)
+		admin.GET("/audit-logs/verify", controllers.VerifyAuditLogs)
+		admin.GET("/audit-logs/export", controllers.ExportAuditLogs)
+		admin.POST("/audit-logs/archive", controllers.ArchiveAuditLogs)
+		admin.GET("/audit-archives", controllers.GetAuditArchives)
+		admin.GET("/audit-archives/:id/download", controllers.DownloadAuditArchive)
 		admin.POST("/work-order", controllers.CreateWorkOrder)
+
+		admin.POST("/machines", controllers.CreateMachine)
+		admin.PUT("/machines/:no_mc", controllers.UpdateMachine)
+
+		admin.POST("/molds", controllers.CreateMold)
+		admin.PUT("/molds/:code", controllers.UpdateMold)
+		admin.POST("/molds/import", controllers.ImportMoldsFromStdLot)
+
+		admin.GET("/lot-formats", controllers.GetLotFormats)
+		admin.PUT("/lot-formats/:proses", controllers.UpdateLotFormat)
+
+		admin.GET("/notification-templates", controllers.GetNotificationTemplates)
+		admin.PUT("/notification-templates/:code", controllers.SaveNotificationTemplate)
+		admin.GET("/notifications", controllers.GetNotificationJobs)
+		admin.POST("/notifications/:id/retry", controllers.RetryNotificationJob)
+		admin.POST("/notifications/test", controllers.SendTestNotification)
+
+		admin.GET("/operator-aliases", controllers.GetOperatorAliases)
+		admin.POST("/operator-aliases", controllers.CreateOperatorAlias)
+		admin.DELETE("/operator-aliases/:id", controllers.DeleteOperatorAlias)
+
+		admin.GET("/leader-processes", controllers.GetLeaderProcesses)
+		admin.POST("/leader-processes", controllers.CreateLeaderProcess)
+		admin.DELETE("/leader-processes/:id", controllers.DeleteLeaderProcess)
+
+		admin.POST("/defects", controllers.CreateDefectType)
+		admin.PUT("/defects/:id", controllers.UpdateDefectType)
 	}
 
 	// 4. GROUP PRODUKSI UMUM (Bisa diakses Admin & Semua Operator)
@@ -78,11 +143,65 @@ admin := r.Group("/admin")
 	{
 		api.GET("/users/profile", controllers.GetUserProfile)
 		api.GET("/dashboard/stats", controllers.GetDashboardStats)
+		api.GET("/processes", controllers.GetProcesses)
+		api.GET("/defects", controllers.GetDefectTypes)
 		api.GET("/pressing/today", controllers.GetPressingDashboard)
 		api.POST("/scan-machine", controllers.ScanMachine)
+		api.POST("/scans", controllers.CreateScan)
+		api.GET("/scans", controllers.GetScans)
 		api.POST("/lwp", controllers.CreateLWP)
+		api.GET("/lwp/reports", controllers.GetLWPReports)
+		api.PUT("/lwp/:id", controllers.UpdateLWPReport)
+		api.POST("/lwp/:id/submit", controllers.SubmitLWPReport)
+
+		// Approval LWP oleh leader
+		api.GET("/lwp/approvals", controllers.GetLWPApprovalQueue)
+		api.POST("/lwp/:id/approve", middleware.ActionMiddleware("LEADER"), controllers.ApproveLWPReport)
+		api.POST("/lwp/:id/return", middleware.ActionMiddleware("LEADER"), controllers.ReturnLWPReport)
+
+		// Koreksi data produksi (tercatat sebagai revisi) & riwayatnya
+		api.POST("/lwp/:id/corrections", middleware.ActionMiddleware("LEADER"), controllers.CorrectLWPReport)
+		api.POST("/cycles/:id/corrections", middleware.ActionMiddleware("LEADER"), controllers.CorrectCycle)
+		api.GET("/revisions/:entity/:id", controllers.GetRevisionHistory)
 		api.GET("/pressing/weekly-stats", controllers.GetPressingWeeklyStats)
 		api.GET("/pressing/lwp-data", controllers.GetPressingLWPData)
+		api.GET("/pressing/lwp-data/export", controllers.ExportPressingLWP)
+
+		// Scorecard operator (output vs standar, FPY, defect mix, peringkat per proses)
+		api.GET("/operators/resolve", controllers.ResolveOperatorIdentity)
+		api.GET("/operators/scorecard", controllers.GetOperatorScorecards)
+		api.GET("/operators/:nik/scorecard", controllers.GetOperatorScorecard)
+
+		// Registry mesin & antrian WO per mesin (untuk operator di mesin)
+		api.GET("/machines", controllers.GetMachines)
+		api.GET("/machines/:no_mc/queue", controllers.GetMachineQueue)
+
+		// Master mold & umur mold (shot count)
+		api.GET("/molds", controllers.GetMolds)
+		api.GET("/molds/alerts", controllers.GetMoldAlerts)
+		api.GET("/molds/:code", controllers.GetMold)
+		api.POST("/molds/:code/maintenance", controllers.RecordMoldMaintenance)
+
+		// Pergantian mold (dandori)
+		api.GET("/mold-changes", controllers.GetMoldChanges)
+		api.GET("/mold-changes/report", controllers.GetMoldChangeReport)
+		api.POST("/mold-changes/start", controllers.StartMoldChange)
+		api.POST("/mold-changes/:id/finish", controllers.FinishMoldChange)
+		api.POST("/mold-changes/infer", controllers.InferMoldChanges)
+
+		// Traceability lot (genealogy cutting -> pressing)
+		api.POST("/lots", controllers.CreateLot)
+		api.GET("/lots", controllers.GetLots)
+		api.GET("/lots/:lot_no", controllers.GetLot)
+		api.GET("/lots/:lot_no/trace", controllers.TraceLot)
+		api.GET("/trace/material/:batch", controllers.TraceMaterialBatch)
+		api.POST("/lot-numbers/reserve", controllers.ReserveLotNumbers)
+
+		// Label barcode / QR (Code128, QR, PNG/SVG/PDF)
+		api.GET("/labels/lot/:lot_no", controllers.GetLotLabel)
+		api.GET("/labels/work-order/:id", controllers.GetWorkOrderLabel)
+		api.POST("/labels/sheet", controllers.PrintLabelSheet)
+		api.POST("/labels/decode", controllers.DecodeLabel)
 
 	}
 
@@ -106,8 +225,121 @@ admin := r.Group("/admin")
     // Level 3a: Klik Mesin -> Lihat Summary/Overview Mesin
     // Usage: GET /api/chart/machine?tanggal=2026-02-01&no_mc=04A
     chartApi.GET("/machine", controllers.GetMachineDetail)
+
+    // Export laporan chart ke Excel / PDF
+    // Usage: GET /api/chart/export?format=xlsx&from=2026-02-01&to=2026-02-07
+    chartApi.GET("/export", controllers.ExportChartReport)
+
+    // Pareto reject sesuai katalog reject
+    // Usage: GET /api/chart/defect-pareto?proses=PRS&from=2026-02-01&to=2026-02-07
+    chartApi.GET("/defect-pareto", controllers.GetDefectPareto)
     
     }
 
+	// -----------------------------------------------------------
+	// 7. GROUP PLANNING (Jadwal Produksi: WO -> Mesin -> Shift)
+	// -----------------------------------------------------------
+	planning := r.Group("/api/schedule")
+	planning.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER"))
+	{
+		planning.GET("", controllers.GetSchedules)
+		planning.POST("", controllers.CreateSchedule)
+		planning.GET("/conflicts", controllers.GetScheduleConflicts)
+		planning.PATCH("/:id/status", controllers.UpdateScheduleStatus)
+		planning.DELETE("/:id", controllers.DeleteSchedule)
+	}
+
+	// -----------------------------------------------------------
+	// 8. GROUP MAINTENANCE (Preventive Maintenance Mesin)
+	// -----------------------------------------------------------
+	pm := r.Group("/api/pm")
+	pm.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER", "MAINTENANCE", "OPERATOR_CUTTING", "OPERATOR_PRESSING"))
+	{
+		pm.GET("/plans", controllers.GetPMPlans)
+		pm.POST("/plans", middleware.ActionMiddleware("MANAGER"), controllers.CreatePMPlan)
+		pm.PUT("/plans/:id", middleware.ActionMiddleware("MANAGER"), controllers.UpdatePMPlan)
+		pm.POST("/generate", controllers.GeneratePMTasks)
+		pm.GET("/tasks", controllers.GetPMTasks)
+		pm.POST("/tasks/:id/complete", controllers.CompletePMTask)
+		pm.GET("/check/:no_mc", controllers.CheckMachinePM)
+	}
+
+	// Tiket kerusakan mesin (operator lapor, maintenance perbaiki)
+	repair := r.Group("/api/repairs")
+	repair.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER", "MAINTENANCE", "OPERATOR_CUTTING", "OPERATOR_PRESSING"))
+	{
+		repair.GET("", controllers.GetRepairTickets)
+		repair.POST("", controllers.CreateRepairTicket)
+		repair.GET("/report", controllers.GetRepairReport)
+		repair.POST("/:id/acknowledge", controllers.AcknowledgeRepairTicket)
+		repair.POST("/:id/close", controllers.CloseRepairTicket)
+	}
+
+	// Andon: alert produksi & rule threshold
+	andon := r.Group("/api")
+	andon.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER", "OPERATOR_CUTTING", "OPERATOR_PRESSING"))
+	{
+		andon.GET("/alerts", controllers.GetAlerts)
+		andon.POST("/alerts/:id/acknowledge", middleware.ActionMiddleware("LEADER"), controllers.AcknowledgeAlert)
+		andon.POST("/alerts/evaluate", middleware.ActionMiddleware("MANAGER"), controllers.TriggerAlertEvaluation)
+		andon.GET("/alert-rules", controllers.GetAlertRules)
+		andon.POST("/alert-rules", middleware.ActionMiddleware("MANAGER"), controllers.CreateAlertRule)
+		andon.PUT("/alert-rules/:id", middleware.ActionMiddleware("MANAGER"), controllers.UpdateAlertRule)
+
+		andon.GET("/notifications/preferences", controllers.GetMyNotificationPreferences)
+		andon.PUT("/notifications/preferences", controllers.SaveMyNotificationPreference)
+	}
+
+	// Master item, mold & standar (v_stdlot) + override standar lokal berversi
+	standards := r.Group("/api/standards")
+	standards.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER"))
+	{
+		standards.GET("", controllers.GetStandards)
+		standards.GET("/items", controllers.GetStandardItems)
+		standards.GET("/molds", controllers.GetStandardMolds)
+		standards.GET("/overrides", controllers.GetStandardOverrides)
+		standards.POST("/overrides", middleware.ActionMiddleware("MANAGER"), controllers.CreateStandardOverride)
+		standards.DELETE("/overrides/:id", middleware.ActionMiddleware("MANAGER"), controllers.DeleteStandardOverride)
+		standards.POST("/snapshot", middleware.ActionMiddleware("MANAGER"), controllers.SnapshotStandards)
+		standards.GET("/impact", controllers.GetStandardImpact)
+	}
+
+	// Disposisi NG (rework / scrap / use-as-is): leader mengajukan, manager approve
+	dispositions := r.Group("/api/dispositions")
+	dispositions.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER", "LEADER"))
+	{
+		dispositions.GET("", controllers.GetDispositions)
+		dispositions.POST("", controllers.CreateDisposition)
+		dispositions.GET("/summary", controllers.GetDispositionSummary)
+		dispositions.POST("/:id/approve", middleware.ActionMiddleware("MANAGER"), controllers.ApproveDisposition)
+		dispositions.POST("/:id/reject", middleware.ActionMiddleware("MANAGER"), controllers.RejectDisposition)
+		dispositions.GET("/material-costs", controllers.GetMaterialCosts)
+		dispositions.PUT("/material-costs/:item_code", middleware.ActionMiddleware("MANAGER"), controllers.SaveMaterialCost)
+	}
+
+	// Laporan terjadwal: definisi jadwal, riwayat eksekusi & download file
+	reports := r.Group("/api/reports")
+	reports.Use(middleware.AuthAndRoleMiddleware("ADMIN", "MANAGER"))
+	{
+		reports.GET("/schedules", controllers.GetReportSchedules)
+		reports.POST("/schedules", controllers.CreateReportSchedule)
+		reports.PUT("/schedules/:id", controllers.UpdateReportSchedule)
+		reports.POST("/schedules/:id/run", controllers.RunReportScheduleNow)
+		reports.GET("/runs", controllers.GetReportRuns)
+		reports.GET("/runs/:id/download", controllers.DownloadReportRun)
+	}
+	// Link download di email laporan: diverifikasi lewat token bertanda tangan, bukan login
+	r.GET("/api/report-files/:id", controllers.DownloadReportRunByToken)
+
+	// Realtime push ke dashboard (SSE). Token boleh lewat ?token= karena EventSource tidak bisa kirim header
+	events := r.Group("/api/events")
+	events.Use(middleware.TokenQueryMiddleware(), middleware.AuthAndRoleMiddleware(
+		"ADMIN", "MANAGER", "LEADER", "OPERATOR_CUTTING", "OPERATOR_PRESSING",
+	))
+	{
+		events.GET("/stream", controllers.StreamEvents)
+		events.GET("/stats", controllers.GetEventStats)
+	}
+
 	r.Run(":8080")
 }
\ No newline at end of file
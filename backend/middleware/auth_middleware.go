@@ -87,4 +87,16 @@ func ActionMiddleware(requiredRole string) gin.HandlerFunc {
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+// TokenQueryMiddleware untuk koneksi EventSource (SSE) yang tidak bisa mengirim header.
+// Token diambil dari ?token= lalu diverifikasi oleh AuthAndRoleMiddleware seperti biasa.
+func TokenQueryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
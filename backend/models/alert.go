@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Aturan andon yang dievaluasi terus-menerus oleh alert engine
+type AlertRule struct {
+	gorm.Model
+	Nama               string  `gorm:"not null" json:"nama"`
+	Type               string  `gorm:"not null" json:"type"`                   // LOW_OUTPUT, HIGH_NG, MACHINE_DOWN, NO_LWP
+	Threshold          float64 `json:"threshold"`                              // % (LOW_OUTPUT, HIGH_NG) atau menit (MACHINE_DOWN, NO_LWP)
+	Proses             string  `json:"proses"`                                 // Kosong = semua proses
+	NoMC               string  `json:"no_mc"`                                  // Kosong = semua mesin
+	EscalateLeaderMin  int     `gorm:"default:0" json:"escalate_leader_min"`   // Menit sebelum naik ke LEADER
+	EscalateManagerMin int     `gorm:"default:30" json:"escalate_manager_min"` // Menit sebelum naik ke MANAGER
+	Aktif              bool    `gorm:"default:true" json:"aktif"`
+}
+
+// Alert andon yang terbuka untuk satu mesin
+type Alert struct {
+	gorm.Model
+	RuleID         uint       `gorm:"index;not null" json:"rule_id"`
+	RuleType       string     `json:"rule_type"`
+	NoMC           string     `gorm:"index" json:"no_mc"`
+	Proses         string     `json:"proses"`
+	Message        string     `json:"message"`
+	Value          float64    `json:"value"`
+	Status         string     `gorm:"index;default:'OPEN'" json:"status"` // OPEN, ACKNOWLEDGED, RESOLVED
+	Level          string     `gorm:"default:'LINE'" json:"level"`        // LINE, LEADER, MANAGER
+	OpenedAt       time.Time  `json:"opened_at"`
+	EscalatedAt    *time.Time `json:"escalated_at"`
+	AcknowledgedBy string     `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+}
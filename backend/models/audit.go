@@ -5,10 +5,38 @@ import (
 )
 
 type AuditLog struct {
-	ID        uint      `gorm:"primaryKey"`
-	UserID    uint      `json:"user_id"`
-	Username  string    `json:"username"`
-	Action    string    `json:"action"`   // misal: "START_MACHINE_CUTTING"
-	Endpoint  string    `json:"endpoint"` // misal: "/production/cutting/start"
-	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `json:"user_id"`
+	Username   string    `gorm:"index" json:"username"` // NIK pelaku
+	Role       string    `json:"role"`
+	IP         string    `json:"ip"`
+	Device     string    `json:"device"` // X-Device-ID atau User-Agent
+	Method     string    `json:"method"`
+	Action     string    `gorm:"index" json:"action"`                       // misal: "START_MACHINE_CUTTING"
+	Endpoint   string    `gorm:"index" json:"endpoint"`                     // misal: "/production/cutting/start"
+	EntityType string    `gorm:"index:idx_audit_entity" json:"entity_type"` // lwp, work_order, user, ...
+	EntityID   string    `gorm:"index:idx_audit_entity" json:"entity_id"`
+	Before     string    `json:"before"` // JSON snapshot sebelum perubahan
+	After      string    `json:"after"`  // JSON snapshot sesudah perubahan
+	Result     string    `json:"result"` // SUCCESS, FAILED
+	Details    string    `json:"details"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `gorm:"index" json:"hash"` // HMAC-SHA256(prev_hash + isi baris), rantai untuk deteksi manipulasi
+	HashAlg    string    `json:"hash_alg"`          // HMAC-SHA256, kosong = SHA-256 tanpa kunci (baris lama)
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// Arsip audit log lama (file JSONL terkompresi gzip) hasil retention policy
+type AuditArchive struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FileName   string    `json:"file_name"`
+	FromID     uint      `json:"from_id"`
+	ToID       uint      `json:"to_id"`
+	Rows       int       `json:"rows"`
+	FromDate   time.Time `json:"from_date"`
+	ToDate     time.Time `json:"to_date"`
+	LastHash   string    `json:"last_hash"`   // Hash baris terakhir yang diarsip (awal rantai baris berikutnya)
+	FileSHA256 string    `json:"file_sha256"` // Checksum file arsip
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
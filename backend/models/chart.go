@@ -6,6 +6,7 @@ type ChartSeries struct {
     Actual    float64 `json:"actual" gorm:"column:actual"`
     ActualOK  float64 `json:"actual_ok,omitempty" gorm:"column:actual_ok"` 
     ActualNG  float64 `json:"actual_ng,omitempty" gorm:"column:actual_ng"`
+    ReworkOK  float64 `json:"rework_ok,omitempty" gorm:"column:rework_ok"` // NG yang kembali OK lewat disposisi REWORK (sudah termasuk di actual_ok)
     
     // UBAH: Ganti ItemName menjadi ItemCode
     ItemCode  string  `json:"item_code" gorm:"column:item_code"`   
@@ -9,5 +9,7 @@ type PerCycle struct {
 	Item         string `json:"item" binding:"required"`          // Nama Item
 	NoLot        string `json:"no_lot" binding:"required"`        // Nomor Lot
 	StatusMesin  string `json:"status_mesin" binding:"required"`  // produksi, mati, rusak, reparasi
+	NIK          string `gorm:"index" json:"nik"`                  // NIK operator (tm_employ)
 	NamaOperator string `json:"nama_operator"`                    // Nama Operator
+	Revision     int    `gorm:"default:1" json:"revision"`         // Naik setiap koreksi
 }
\ No newline at end of file
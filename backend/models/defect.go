@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// Katalog jenis reject per proses (dikelola admin).
+// Column = kolom qty di sumber data proses (misal vtrx_lwp_prs.Bintik); kosong = jenis baru
+// yang hanya dicatat lewat input LWP aplikasi.
+type DefectType struct {
+	gorm.Model
+	Proses   string `gorm:"uniqueIndex:idx_defect_proses_code;not null" json:"proses"` // CUT, PRS, FIN
+	Code     string `gorm:"uniqueIndex:idx_defect_proses_code;not null" json:"code"`   // Contoh: BINTIK
+	Label    string `json:"label"`                                                     // Contoh: Bintik
+	Column   string `json:"column"`
+	Severity string `gorm:"default:'MINOR'" json:"severity"` // MINOR, MAJOR, CRITICAL
+	Urutan   int    `json:"urutan"`                          // Urutan tampil di form LWP & laporan
+	Aktif    bool   `gorm:"default:true" json:"aktif"`
+}
+
+// Qty reject per jenis yang diinput operator pada satu laporan LWP
+type LWPDefect struct {
+	gorm.Model
+	LWPReportID uint   `gorm:"index" json:"lwp_report_id"`
+	Proses      string `gorm:"index" json:"proses"`
+	Code        string `gorm:"index" json:"code"`
+	Qty         int    `json:"qty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Disposisi NG dari satu laporan LWP: REWORK (kembali jadi OK), SCRAP (dibuang) atau
+// USE_AS_IS (dipakai apa adanya dengan konsesi). Alur: PENDING -> APPROVED / REJECTED.
+type NGDisposition struct {
+	gorm.Model
+	LWPReportID     uint       `gorm:"index" json:"lwp_report_id"`
+	NoLot           string     `gorm:"index" json:"no_lot"`
+	NoMC            string     `gorm:"index" json:"no_mc"`
+	Proses          string     `json:"proses"`
+	ItemCode        string     `gorm:"index" json:"item_code"`
+	Tanggal         string     `gorm:"index" json:"tanggal"` // Tanggal produksi LWP (YYYY-MM-DD)
+	Outcome         string     `gorm:"index" json:"outcome"` // REWORK, SCRAP, USE_AS_IS
+	Qty             int        `json:"qty"`
+	DefectCode      string     `json:"defect_code"` // Opsional, dari katalog reject
+	Reason          string     `json:"reason"`
+	RequestedBy     string     `json:"requested_by"`                          // NIK
+	Status          string     `gorm:"index;default:'PENDING'" json:"status"` // PENDING, APPROVED, REJECTED
+	ApprovedBy      string     `json:"approved_by"`                           // NIK approver
+	ApprovedAt      *time.Time `json:"approved_at"`
+	ApprovalComment string     `json:"approval_comment"`
+	ReworkLotNo     string     `json:"rework_lot_no"` // Lot hasil rework (turunan lot asal)
+	UnitCost        float64    `json:"unit_cost"`     // Biaya material per pcs saat approval (SCRAP)
+	ScrapCost       float64    `json:"scrap_cost"`    // Qty x UnitCost
+}
+
+// Biaya material per pcs untuk menghitung biaya scrap
+type MaterialCost struct {
+	gorm.Model
+	ItemCode   string  `gorm:"uniqueIndex;not null" json:"item_code"`
+	CostPerPcs float64 `json:"cost_per_pcs"`
+	Currency   string  `gorm:"default:'IDR'" json:"currency"`
+	UpdatedBy  string  `json:"updated_by"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Lot produksi (cutting, pressing, finishing) untuk keperluan traceability
+type Lot struct {
+	gorm.Model
+	LotNo         string     `gorm:"unique;not null" json:"lot_no"`
+	Proses        string     `gorm:"index" json:"proses"` // CUT, PRS, FIN, RWK (hasil rework NG)
+	ItemCode      string     `json:"item_code"`
+	MaterialBatch string     `gorm:"index" json:"material_batch"` // Batch material (compound / metal)
+	NoMC          string     `json:"no_mc"`
+	MoldCode      string     `json:"mold_code"`
+	OperatorNIK   string     `json:"operator_nik"`
+	WorkOrderID   uint       `json:"work_order_id"`
+	Qty           int        `json:"qty"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+
+	Parents []LotLink `gorm:"foreignKey:ChildLotID" json:"parents,omitempty"`
+}
+
+// Relasi lot induk -> lot turunan (misal lot cutting dipakai untuk lot pressing)
+type LotLink struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ParentLotID uint      `gorm:"index;not null" json:"parent_lot_id"`
+	ChildLotID  uint      `gorm:"index;not null" json:"child_lot_id"`
+	ParentLotNo string    `json:"parent_lot_no"`
+	ChildLotNo  string    `json:"child_lot_no"`
+	Qty         int       `json:"qty"` // Qty dari lot induk yang terpakai
+	CreatedAt   time.Time `json:"created_at"`
+}
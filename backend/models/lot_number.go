@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Format nomor lot per proses. Token: {PROC} {MC} {YYMMDD} {YYYYMMDD} {SEQ} / {SEQ:5}
+type LotNumberFormat struct {
+	gorm.Model
+	Proses  string `gorm:"unique;not null" json:"proses"`
+	Pattern string `gorm:"not null" json:"pattern"` // Contoh: {PROC}{MC}-{YYMMDD}-{SEQ}
+}
+
+// Counter urutan lot per proses/mesin/hari (naik secara atomic)
+type LotSequence struct {
+	ID        uint   `gorm:"primaryKey"`
+	SeqKey    string `gorm:"unique;not null"` // PROSES|MC|YYYYMMDD, atau PATTERN|token... (lihat lotSequenceKey)
+	LastSeq   int    `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+// Nomor lot yang sudah dikeluarkan (dipesan saat label dicetak, dipakai saat LWP disimpan)
+type LotReservation struct {
+	gorm.Model
+	LotNo       string `gorm:"unique;not null" json:"lot_no"`
+	Proses      string `json:"proses"`
+	NoMC        string `json:"no_mc"`
+	WorkOrderID uint   `json:"work_order_id"`
+	Status      string `gorm:"default:'RESERVED'" json:"status"` // RESERVED, USED, VOID
+	ReservedBy  string `json:"reserved_by"`
+}
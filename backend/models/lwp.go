@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Laporan Waktu Produksi (LWP) dengan alur persetujuan leader:
+// DRAFT -> SUBMITTED -> APPROVED / RETURNED (dikembalikan dengan komentar, bisa diedit & submit ulang)
+type LWPReport struct {
+	gorm.Model
+	CycleID       uint       `gorm:"index" json:"cycle_id"` // PerCycle yang dibuat bersamaan
+	NoMC          string     `gorm:"index" json:"no_mc"`
+	Proses        string     `gorm:"index" json:"proses"`  // Dari registry mesin
+	Tanggal       string     `gorm:"index" json:"tanggal"` // YYYY-MM-DD
+	Shift         string     `json:"shift"`
+	NIK           string     `gorm:"index" json:"nik"`
+	PartName      string     `json:"part_name"`
+	KodePart      string     `json:"kode_part"`              // Kode mold
+	ItemCode      string     `gorm:"index" json:"item_code"` // Item produk (kunci biaya material)
+	JamMulai      string     `json:"jam_mulai"`
+	JamSelesai    string     `json:"jam_selesai"`
+	HasilOK       int        `json:"hasil_ok"`
+	NG            int        `json:"ng"`
+	ReworkOK      int        `json:"rework_ok"` // NG yang kembali jadi OK lewat disposisi REWORK
+	NoLot         string     `gorm:"index" json:"no_lot"`
+	Status        string     `gorm:"index;default:'DRAFT'" json:"status"` // DRAFT, SUBMITTED, APPROVED, RETURNED
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	ReviewedBy    string     `json:"reviewed_by"` // NIK leader
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewComment string     `json:"review_comment"`
+	Revision      int        `gorm:"default:1" json:"revision"` // Naik setiap koreksi (lihat models.Revision)
+}
+
+// Penugasan leader ke proses: menentukan antrian approval & penerima notifikasi LWP baru
+type LeaderProcess struct {
+	gorm.Model
+	NIK       string `gorm:"uniqueIndex:idx_leader_proses;not null" json:"nik"`
+	Proses    string `gorm:"uniqueIndex:idx_leader_proses;not null" json:"proses"`
+	CreatedBy string `json:"created_by"`
+}
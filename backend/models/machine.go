@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// Master mesin (registry). NoMC disamakan dengan kolom noMC di vtrx_lwp_prs
+type Machine struct {
+	gorm.Model
+	NoMC   string `gorm:"unique;not null" json:"no_mc"` // Contoh: 04A
+	Nama   string `json:"nama"`
+	Proses string `gorm:"index" json:"proses"`          // CUT, PRS, FIN
+	Status string `gorm:"default:'IDLE'" json:"status"` // IDLE, RUNNING, DOWN, REPAIR, PM
+	Aktif  bool   `gorm:"default:true" json:"aktif"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rencana preventive maintenance per mesin
+type PMPlan struct {
+	gorm.Model
+	NoMC          string     `gorm:"index;not null" json:"no_mc"`
+	Nama          string     `gorm:"not null" json:"nama"`             // Contoh: Ganti oli hidrolik
+	TriggerType   string     `gorm:"not null" json:"trigger_type"`     // CALENDAR (hari), RUN_HOURS (jam), SHOTS
+	IntervalValue float64    `gorm:"not null" json:"interval_value"`   // Hari / jam jalan / shot
+	Critical      bool       `json:"critical"`                         // Jika overdue, mesin tidak boleh start
+	Checklist     []string   `gorm:"serializer:json" json:"checklist"` // Item yang harus dicek teknisi
+	LastDoneAt    *time.Time `json:"last_done_at"`
+	LastDoneValue float64    `json:"last_done_value"` // Counter jam / shot saat PM terakhir
+	Aktif         bool       `gorm:"default:true" json:"aktif"`
+}
+
+// Hasil cek satu item checklist PM
+type ChecklistResult struct {
+	Item string `json:"item"`
+	OK   bool   `json:"ok"`
+	Note string `json:"note"`
+}
+
+// Tugas PM yang di-generate dari PMPlan
+type PMTask struct {
+	gorm.Model
+	PlanID          uint              `gorm:"index;not null" json:"plan_id"`
+	NoMC            string            `gorm:"index;not null" json:"no_mc"`
+	Nama            string            `json:"nama"`
+	TriggerType     string            `json:"trigger_type"`
+	Critical        bool              `json:"critical"`
+	DueAt           *time.Time        `json:"due_at"`                       // Untuk CALENDAR
+	DueValue        float64           `json:"due_value"`                    // Untuk RUN_HOURS / SHOTS
+	Status          string            `gorm:"default:'OPEN'" json:"status"` // OPEN, DONE, CANCELLED (plan diubah / dinonaktifkan)
+	CompletedAt     *time.Time        `json:"completed_at"`
+	TechnicianNIK   string            `json:"technician_nik"`
+	ChecklistResult []ChecklistResult `gorm:"serializer:json" json:"checklist_result"`
+	Note            string            `json:"note"`
+}
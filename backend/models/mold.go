@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Master mold (cetakan). MoldCode disamakan dengan moldCode di vtrx_lwp_prs & v_stdlot
+type Mold struct {
+	gorm.Model
+	MoldCode         string  `gorm:"unique;not null" json:"mold_code"`
+	MoldName         string  `json:"mold_name"`
+	ItemCode         string  `json:"item_code"`
+	Cavity           int     `gorm:"not null;default:1" json:"cavity"`
+	StdCycleSec      float64 `json:"std_cycle_sec"`                  // Standar cycle time per shot (detik)
+	MaxShot          int     `json:"max_shot"`                       // Umur maksimal mold (shot)
+	CleaningInterval int     `json:"cleaning_interval"`              // Cleaning tiap N shot (0 = tidak dipantau)
+	PMInterval       int     `json:"pm_interval"`                    // Preventive maintenance tiap N shot
+	ShotOffset       int     `json:"shot_offset"`                    // Shot sebelum mold tercatat di sistem
+	LastCleaningShot int     `json:"last_cleaning_shot"`             // Counter shot saat cleaning terakhir
+	LastPMShot       int     `json:"last_pm_shot"`                   // Counter shot saat PM terakhir
+	Status           string  `gorm:"default:'ACTIVE'" json:"status"` // ACTIVE, MAINTENANCE, SCRAPPED
+}
+
+// Riwayat cleaning / PM mold
+type MoldMaintenance struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	MoldCode  string    `gorm:"index;not null" json:"mold_code"`
+	Type      string    `gorm:"not null" json:"type"` // CLEANING, PM
+	ShotAt    int       `json:"shot_at"`              // Counter shot saat dikerjakan
+	DoneBy    string    `json:"done_by"`              // NIK teknisi
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
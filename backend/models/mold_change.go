@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Pergantian mold (dandori) di satu mesin
+type MoldChange struct {
+	gorm.Model
+	NoMC         string     `gorm:"index;not null" json:"no_mc"`
+	FromMold     string     `json:"from_mold"`
+	ToMold       string     `json:"to_mold"`
+	StartedAt    time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	SetupMinutes float64    `json:"setup_minutes"`
+	OperatorNIK  string     `json:"operator_nik"`
+	Source       string     `gorm:"default:'MANUAL'" json:"source"` // MANUAL (start/finish), INFERRED (dari LWP)
+	Note         string     `json:"note"`
+}
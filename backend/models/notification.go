@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Template pesan per jenis event (text/template Go, contoh: "Alert MC {{.no_mc}}")
+type NotificationTemplate struct {
+	gorm.Model
+	Code    string `gorm:"unique;not null" json:"code"` // Contoh: alert.escalated, lwp.submitted
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Preferensi channel notifikasi per user
+type NotificationPreference struct {
+	gorm.Model
+	NIK        string   `gorm:"index;not null" json:"nik"`
+	Role       string   `gorm:"index" json:"role"`                  // Disalin dari token saat preferensi disimpan
+	Channel    string   `gorm:"not null" json:"channel"`            // email, webhook, chat
+	Target     string   `json:"target"`                             // Email / URL webhook (kosong = email dari tm_employ)
+	EventTypes []string `gorm:"serializer:json" json:"event_types"` // Kosong = semua event
+	Aktif      bool     `gorm:"default:true" json:"aktif"`
+}
+
+// Antrian pengiriman notifikasi (persisten, dengan retry)
+type NotificationJob struct {
+	gorm.Model
+	EventCode     string     `gorm:"index" json:"event_code"`
+	NIK           string     `gorm:"index" json:"nik"`
+	Channel       string     `json:"channel"`
+	Target        string     `json:"target"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `gorm:"index;default:'PENDING'" json:"status"` // PENDING, SENDING, SENT, FAILED
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "gorm.io/gorm"
+
+// Alias identitas operator: NPK lama di vtrx_lwp_prs atau nama lama (sebelum ganti nama)
+// yang harus dipetakan ke NIK di tm_employ
+type OperatorAlias struct {
+	gorm.Model
+	NIK       string `gorm:"index;not null" json:"nik"`
+	Kind      string `gorm:"not null" json:"kind"` // NPK, NAMA
+	Alias     string `gorm:"uniqueIndex;not null" json:"alias"`
+	Note      string `json:"note"`
+	CreatedBy string `json:"created_by"`
+}
+
+// Identitas operator hasil resolusi (bukan tabel)
+type OperatorIdentity struct {
+	NIK      string   `json:"nik"`
+	Nama     string   `json:"nama"`     // Nama tampilan dari tm_employ
+	NPKs     []string `json:"npks"`     // Semua NPK milik operator ini di vtrx_lwp_prs
+	Verified bool     `json:"verified"` // false jika tm_employ tidak bisa dicek (MySQL mati)
+}
@@ -8,9 +8,11 @@ type WorkOrder struct {
 	ID           uint      `gorm:"primaryKey"`
 	OrderNumber  string    `gorm:"unique;not null"` // Contoh: WO-2026-001
 	PartName     string    `gorm:"not null"`        // Nama spare part
+	ItemCode     string    `gorm:"index"`           // Kode item (v_stdlot), dipakai di payload label
 	Quantity     int       `gorm:"not null"`        // Jumlah yang harus dibuat
 	Status       string    `gorm:"default:'PENDING'"` // PENDING, CUTTING, PRESSING, DONE
 	OperatorID   uint      // Siapa yang sedang mengerjakan
+	Revision     int       `gorm:"default:1"` // Naik setiap perubahan (lihat models.Revision)
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
\ No newline at end of file
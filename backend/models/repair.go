@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tiket kerusakan mesin: operator lapor -> maintenance acknowledge -> perbaikan -> close
+type RepairTicket struct {
+	gorm.Model
+	NoMC           string     `gorm:"index;not null" json:"no_mc"`
+	Status         string     `gorm:"index;default:'OPEN'" json:"status"` // OPEN, ACKNOWLEDGED, CLOSED
+	Symptom        string     `json:"symptom"`                            // Keluhan dari operator
+	ReportedBy     string     `json:"reported_by"`                        // NIK operator
+	ReportedAt     time.Time  `json:"reported_at"`
+	AcknowledgedBy string     `json:"acknowledged_by"` // NIK teknisi
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	Cause          string     `json:"cause"`  // Penyebab kerusakan
+	Action         string     `json:"action"` // Tindakan perbaikan
+	ClosedBy       string     `json:"closed_by"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	CycleID        uint       `json:"cycle_id"` // PerCycle "rusak" yang memicu tiket (jika ada)
+}
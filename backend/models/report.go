@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Definisi laporan terjadwal (cron 5 field: menit jam tanggal bulan hari)
+type ReportSchedule struct {
+	gorm.Model
+	Name      string     `gorm:"not null" json:"name"`
+	Kind      string     `gorm:"not null;default:'DAILY_SUMMARY'" json:"kind"` // DAILY_SUMMARY
+	Cron      string     `gorm:"not null" json:"cron"`                         // Contoh: "30 6 * * *" = tiap hari 06:30
+	Format    string     `gorm:"default:'xlsx'" json:"format"`                 // xlsx, pdf
+	Roles     []string   `gorm:"serializer:json" json:"roles"`                 // Penerima berdasarkan role
+	NIKs      []string   `gorm:"serializer:json" json:"niks"`                  // Penerima tambahan per NIK
+	Aktif     bool       `gorm:"default:true" json:"aktif"`
+	CreatedBy string     `json:"created_by"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	NextRunAt *time.Time `gorm:"index" json:"next_run_at"`
+}
+
+// Riwayat eksekusi laporan terjadwal / manual
+type ReportRun struct {
+	gorm.Model
+	ScheduleID  uint       `gorm:"index" json:"schedule_id"`
+	ReportDate  string     `json:"report_date"`         // Tanggal data yang dilaporkan (YYYY-MM-DD)
+	Status      string     `gorm:"index" json:"status"` // RUNNING, SUCCESS, FAILED
+	TriggeredBy string     `json:"triggered_by"`        // SCHEDULER atau NIK
+	FileName    string     `json:"file_name"`
+	Summary     string     `json:"summary"`
+	Error       string     `json:"error"`
+	Notified    int        `json:"notified"` // Jumlah job notifikasi yang dibuat
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
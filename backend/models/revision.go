@@ -0,0 +1,22 @@
+package models
+
+import "gorm.io/gorm"
+
+// Satu field yang berubah pada koreksi data
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Riwayat koreksi data produksi (LWP, cycle, work order, user).
+// Baris utama selalu berisi revisi terbaru, tabel ini menyimpan siapa, kapan, kenapa & nilai sebelum/sesudah.
+type Revision struct {
+	gorm.Model
+	EntityType string        `gorm:"index:idx_revision_entity;not null" json:"entity_type"` // lwp, cycle, work_order, user
+	EntityID   uint          `gorm:"index:idx_revision_entity;not null" json:"entity_id"`
+	Revision   int           `json:"revision"` // Revisi 1 = data asli, koreksi pertama = 2
+	ChangedBy  string        `json:"changed_by"`
+	Reason     string        `json:"reason"`
+	Changes    []FieldChange `gorm:"serializer:json" json:"changes"`
+}
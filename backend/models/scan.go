@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Setiap scan dari perangkat (mesin, lot, badge operator) dicatat apa adanya, valid maupun tidak
+type ScanEvent struct {
+	gorm.Model
+	Type       string    `gorm:"index;not null" json:"type"` // MACHINE, LOT, BADGE
+	Code       string    `gorm:"not null" json:"code"`       // Isi mentah hasil scan
+	DeviceID   string    `gorm:"index" json:"device_id"`
+	NoMC       string    `gorm:"index" json:"no_mc"`
+	LotNo      string    `gorm:"index" json:"lot_no"`
+	NIK        string    `json:"nik"`
+	Action     string    `json:"action"` // START, STOP (khusus scan LOT)
+	ScheduleID uint      `json:"schedule_id"`
+	ScannedAt  time.Time `gorm:"index" json:"scanned_at"`
+	Valid      bool      `json:"valid"`
+	Message    string    `json:"message"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jadwal produksi: satu Work Order dialokasikan ke satu mesin pada satu shift
+type Schedule struct {
+	gorm.Model
+	WorkOrderID  uint      `gorm:"index;not null" json:"work_order_id"`
+	NoMC         string    `gorm:"index;not null" json:"no_mc"`
+	ItemCode     string    `json:"item_code"`
+	MoldCode     string    `json:"mold_code"`
+	Tanggal      string    `gorm:"index" json:"tanggal"` // YYYY-MM-DD
+	Shift        string    `json:"shift"`                // 1, 2, 3 (sama dengan filter di chart mesin)
+	PlannedStart time.Time `json:"planned_start"`
+	PlannedEnd   time.Time `json:"planned_end"`
+	Qty          int       `json:"qty"`
+	Status       string    `gorm:"default:'PLANNED'" json:"status"` // PLANNED, RUNNING, DONE, CANCELLED
+	CreatedBy    string    `json:"created_by"`                      // NIK pembuat jadwal
+
+	WorkOrder WorkOrder `gorm:"foreignKey:WorkOrderID" json:"work_order,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "gorm.io/gorm"
+
+// Versi standar produksi (target qty per jam) per item + mold, opsional per mesin.
+// Source ERP = snapshot v_stdlot saat nilainya berubah (agar histori tidak ikut berubah),
+// MANUAL = override engineering. Prioritas: MANUAL mesin -> MANUAL semua mesin -> ERP.
+// Setiap perubahan adalah versi baru; EffectiveTo diisi otomatis dari versi berikutnya.
+type StandardOverride struct {
+	gorm.Model
+	ItemCode      string  `gorm:"index:idx_std_key;not null" json:"item_code"`
+	MoldCode      string  `gorm:"index:idx_std_key;not null" json:"mold_code"`
+	NoMC          string  `gorm:"index:idx_std_key;default:''" json:"no_mc"`        // Kosong = semua mesin
+	Source        string  `gorm:"index:idx_std_key;default:'MANUAL'" json:"source"` // MANUAL, ERP
+	TgtQtyPJam    float64 `gorm:"not null" json:"tgt_qty_pjam"`                     // 0 (MANUAL) = kembali ke level di bawahnya
+	CycleTimeSec  float64 `json:"cycle_time_sec"`                                   // Opsional: sumber perhitungan target
+	Cavity        int     `json:"cavity"`
+	EffectiveFrom string  `gorm:"index;not null" json:"effective_from"` // YYYY-MM-DD
+	EffectiveTo   string  `json:"effective_to"`                         // YYYY-MM-DD, kosong = masih berlaku
+	Reason        string  `json:"reason"`
+	CreatedBy     string  `json:"created_by"`
+}
+
+// Segmen standar yang sudah diselesaikan prioritasnya (untuk join ke query MySQL)
+type StandardSegment struct {
+	ItemCode   string
+	MoldCode   string
+	NoMC       string
+	TgtQtyPJam float64
+	From       string
+	To         string
+}
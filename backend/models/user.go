@@ -6,7 +6,7 @@ type User struct {
 	gorm.Model
 	Username string `gorm:"unique;not null" json:"username"`
 	Password string `gorm:"not null" json:"-"` // "-" supaya password tidak bocor di JSON
-	Role     string `gorm:"not null" json:"role"` // ADMIN, CUTTING, PRESSING
+	Role     string `gorm:"not null" json:"role"` // ADMIN, CUTTING, PRESSING, MANAGER, LEADER, MAINTENANCE
 }
 
 // Struct untuk request body saat registrasi/tambah operator
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Channel adalah satu cara pengiriman notifikasi (email, webhook, chat)
+type Channel interface {
+	Send(target, subject, body string) error
+}
+
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	// Redirect juga harus tetap ke host yang diizinkan
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("terlalu banyak redirect")
+		}
+		return checkWebhookURL(req.URL.String())
+	},
+}
+
+// Helper: Host webhook / chat yang diizinkan dari NOTIFY_WEBHOOK_HOSTS (dipisah koma, subdomain ikut diizinkan)
+func allowedWebhookHosts() []string {
+	var hosts []string
+	for _, h := range strings.Split(os.Getenv("NOTIFY_WEBHOOK_HOSTS"), ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// Helper: Tolak URL webhook di luar allow-list (mencegah server dipakai memanggil alamat internal)
+func checkWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("URL webhook tidak valid")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("skema %s tidak diizinkan", u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowedWebhookHosts() {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s tidak ada di NOTIFY_WEBHOOK_HOSTS", host)
+}
+
+// ValidateTarget memeriksa target preferensi sebelum disimpan / dikirim
+func ValidateTarget(channel, target string) error {
+	switch channel {
+	case "webhook", "chat":
+		return checkWebhookURL(target)
+	case "email":
+		if target != "" && !strings.Contains(target, "@") {
+			return errors.New("alamat email tidak valid")
+		}
+	}
+	return nil
+}
+
+// Helper: Ambil env dengan nilai default
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// SMTPChannel mengirim email. Default ke localhost:1025 (MailHog / stand-in lokal).
+type SMTPChannel struct {
+	Host string
+	Port string
+	User string
+	Pass string
+	From string
+}
+
+func NewSMTPChannelFromEnv() *SMTPChannel {
+	return &SMTPChannel{
+		Host: env("SMTP_HOST", "localhost"),
+		Port: env("SMTP_PORT", "1025"),
+		User: os.Getenv("SMTP_USER"),
+		Pass: os.Getenv("SMTP_PASS"),
+		From: env("SMTP_FROM", "besq-factory@localhost"),
+	}
+}
+
+func (s *SMTPChannel) Send(target, subject, body string) error {
+	if target == "" {
+		return errors.New("alamat email kosong")
+	}
+
+	var auth smtp.Auth
+	if s.User != "" {
+		auth = smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	}
+
+	target = headerValue(target)
+	msg := smtpMessage(s.From, target, subject, body)
+	return smtp.SendMail(s.Host+":"+s.Port, auth, s.From, []string{target}, []byte(msg))
+}
+
+// Helper: Nilai header tanpa CR / LF (subject dari template bisa berisi data user, cegah injeksi header)
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+// Helper: Susun pesan email plain text; subject di-encode RFC 2047 jika berisi karakter non-ASCII
+func smtpMessage(from, to, subject, body string) string {
+	return strings.Join([]string{
+		"From: " + headerValue(from),
+		"To: " + headerValue(to),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", headerValue(subject)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+}
+
+// Helper: POST JSON dan anggap status selain 2xx sebagai gagal
+func postJSON(url string, payload interface{}) error {
+	if err := checkWebhookURL(url); err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook membalas status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookChannel mengirim JSON generik {subject, body, sent_at}
+type WebhookChannel struct{}
+
+func (WebhookChannel) Send(target, subject, body string) error {
+	if target == "" {
+		return errors.New("URL webhook kosong")
+	}
+	return postJSON(target, map[string]interface{}{
+		"subject": subject,
+		"body":    body,
+		"sent_at": time.Now(),
+	})
+}
+
+// ChatChannel mengirim format incoming-webhook chat ({"text": ...}, kompatibel Slack / Mattermost / Google Chat)
+type ChatChannel struct{}
+
+func (ChatChannel) Send(target, subject, body string) error {
+	if target == "" {
+		return errors.New("URL chat webhook kosong")
+	}
+	return postJSON(target, map[string]string{"text": "*" + subject + "*\n" + body})
+}
+
+// Channels yang tersedia, bisa diganti (misal untuk stand-in lokal)
+var Channels = map[string]Channel{
+	"email":   NewSMTPChannelFromEnv(),
+	"webhook": WebhookChannel{},
+	"chat":    ChatChannel{},
+}
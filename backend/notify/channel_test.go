@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckWebhookURLAllowList(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "hooks.slack.com, chat.example.co.id")
+
+	allowed := []string{
+		"https://hooks.slack.com/services/T000/B000/XXX",
+		"https://team.chat.example.co.id/hook",
+	}
+	for _, u := range allowed {
+		if err := checkWebhookURL(u); err != nil {
+			t.Errorf("%s seharusnya diizinkan: %v", u, err)
+		}
+	}
+
+	blocked := []string{
+		"http://127.0.0.1:8080/admin",
+		"http://169.254.169.254/latest/meta-data",
+		"https://evilhooks.slack.com.attacker.io/x",
+		"file:///etc/passwd",
+		"bukan url",
+	}
+	for _, u := range blocked {
+		if err := checkWebhookURL(u); err == nil {
+			t.Errorf("%s seharusnya ditolak", u)
+		}
+	}
+}
+
+func TestCheckWebhookURLEmptyAllowList(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "")
+	if err := checkWebhookURL("https://hooks.slack.com/x"); err == nil {
+		t.Error("tanpa allow-list semua webhook seharusnya ditolak")
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "hooks.slack.com")
+	if err := ValidateTarget("chat", "http://localhost:9000"); err == nil {
+		t.Error("target chat ke localhost seharusnya ditolak")
+	}
+	if err := ValidateTarget("email", "bukan-email"); err == nil {
+		t.Error("email tanpa @ seharusnya ditolak")
+	}
+	if err := ValidateTarget("email", ""); err != nil {
+		t.Errorf("email kosong (pakai tm_employ) seharusnya boleh: %v", err)
+	}
+}
+
+func TestChatChannelPayload(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "127.0.0.1")
+
+	if err := (ChatChannel{}).Send(srv.URL, "Judul", "Isi"); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	if got["text"] != "*Judul*\nIsi" {
+		t.Errorf("text = %q", got["text"])
+	}
+}
+
+func TestWebhookRedirectOutsideAllowList(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect ke host internal tidak boleh diikuti")
+	}))
+	defer internal.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:"+internal.Listener.Addr().String()[len("127.0.0.1:"):], http.StatusFound)
+	}))
+	defer srv.Close()
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "127.0.0.1")
+
+	if err := (WebhookChannel{}).Send(srv.URL, "s", "b"); err == nil {
+		t.Error("redirect ke host di luar allow-list seharusnya gagal")
+	}
+}
+
+func TestSMTPMessageStripsHeaderInjection(t *testing.T) {
+	msg := smtpMessage("api@localhost", "a@x.com\r\nBcc: evil@x.com", "Mesin rusak\r\nBcc: evil@x.com", "isi")
+	header := msg[:strings.Index(msg, "\r\n\r\n")]
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("header Bcc berhasil disisipkan:\n%s", header)
+		}
+	}
+	if !strings.Contains(msg, "Subject: Mesin rusak  Bcc: evil@x.com") {
+		t.Errorf("subject tidak dibersihkan dari CR/LF:\n%s", header)
+	}
+}
+
+func TestSMTPMessageEncodesNonASCIISubject(t *testing.T) {
+	msg := smtpMessage("api@localhost", "a@x.com", "Mesin rusak – tiket", "isi")
+	if !strings.Contains(msg, "Subject: =?UTF-8?q?") {
+		t.Errorf("subject non-ASCII tidak di-encode:\n%s", msg)
+	}
+}
@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"bytes"
+	"factory-api/database"
+	"factory-api/models"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+)
+
+// Batas percobaan kirim sebelum job dianggap FAILED
+const MaxAttempts = 5
+
+// Job SENDING lebih lama dari ini dianggap tertinggal (proses mati saat mengirim) dan dikembalikan ke PENDING
+const ClaimTimeout = 10 * time.Minute
+
+// Template bawaan, dipakai jika admin belum membuat template di database
+var defaultTemplates = map[string][2]string{
+	"alert.escalated": {"[ANDON] {{.level}} - Mesin {{.no_mc}}", "{{.message}}\nDibuka: {{.opened_at}}\nLevel: {{.level}}"},
+	"lwp.submitted":   {"[LWP] Menunggu approval - Mesin {{.no_mc}}", "LWP #{{.id}} ({{.proses}}) tanggal {{.tanggal}} shift {{.shift}} oleh {{.nik}}\nLot {{.no_lot}}: OK {{.ok}}, NG {{.ng}}"},
+	"lwp.approved":    {"[LWP] Disetujui - Lot {{.no_lot}}", "LWP #{{.id}} mesin {{.no_mc}} tanggal {{.tanggal}} sudah di-approve.\n{{.comment}}"},
+	"lwp.returned":    {"[LWP] Dikembalikan - Lot {{.no_lot}}", "LWP #{{.id}} mesin {{.no_mc}} tanggal {{.tanggal}} dikembalikan leader.\nKomentar: {{.comment}}"},
+	"repair.opened":   {"[REPAIR] Mesin {{.no_mc}} rusak - tiket #{{.id}}", "Gejala: {{.symptom}}\nDilaporkan oleh {{.reported_by}} pada {{.reported_at}}"},
+	"report.ready":    {"[LAPORAN] {{.name}} - {{.tanggal}}", "{{.summary}}\n\nDownload: {{.url}}"},
+	"ng.disposition":  {"[NG] Disposisi {{.outcome}} menunggu approval - Lot {{.no_lot}}", "Disposisi #{{.id}} mesin {{.no_mc}} tanggal {{.tanggal}}: {{.outcome}} {{.qty}} pcs\nAlasan: {{.reason}}\nDiajukan oleh {{.requested_by}}"},
+	"test":            {"Tes notifikasi BESQ", "Notifikasi dari BESQ Factory API berhasil dikirim ke {{.target}}."},
+}
+
+// Helper: Render subject & body dari template database (atau bawaan)
+func render(code string, data map[string]interface{}) (string, string, error) {
+	subject, body := code, fmt.Sprintf("%v", data)
+	if def, ok := defaultTemplates[code]; ok {
+		subject, body = def[0], def[1]
+	}
+
+	var tpl models.NotificationTemplate
+	if err := database.DB.Where("code = ?", code).First(&tpl).Error; err == nil {
+		subject, body = tpl.Subject, tpl.Body
+	}
+
+	exec := func(text string) (string, error) {
+		t, err := template.New(code).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	s, err := exec(subject)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := exec(body)
+	return s, b, err
+}
+
+// Helper: Email default dari tm_employ jika preferensi email tidak punya target
+func resolveTarget(pref models.NotificationPreference) string {
+	if pref.Target != "" || pref.Channel != "email" || database.MySQL == nil {
+		return pref.Target
+	}
+	var emp models.TmEmploy
+	if err := database.MySQL.Where("nik = ?", pref.NIK).First(&emp).Error; err == nil {
+		return emp.EmailAddr
+	}
+	return ""
+}
+
+// Helper: Apakah preferensi berlangganan event ini
+func subscribed(pref models.NotificationPreference, code string) bool {
+	if len(pref.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range pref.EventTypes {
+		if t == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: Render template lalu simpan job dengan status awal tertentu
+func createJob(code, nik, channel, target, status string, data map[string]interface{}) (*models.NotificationJob, error) {
+	subject, body, err := render(code, data)
+	if err != nil {
+		return nil, fmt.Errorf("template %s error: %w", code, err)
+	}
+
+	job := models.NotificationJob{
+		EventCode:     code,
+		NIK:           nik,
+		Channel:       channel,
+		Target:        target,
+		Subject:       subject,
+		Body:          body,
+		Status:        status,
+		NextAttemptAt: time.Now(),
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Enqueue menyimpan job pengiriman ke antrian
+func Enqueue(code, nik, channel, target string, data map[string]interface{}) (*models.NotificationJob, error) {
+	return createJob(code, nik, channel, target, "PENDING", data)
+}
+
+// SendNow langsung mengirim satu job tanpa lewat antrian (job tidak akan diambil worker).
+// Job nil berarti gagal sebelum dikirim (template / database).
+func SendNow(code, nik, channel, target string, data map[string]interface{}) (*models.NotificationJob, error) {
+	job, err := createJob(code, nik, channel, target, "SENDING", data)
+	if err != nil {
+		return nil, err
+	}
+	return job, Deliver(job)
+}
+
+// Helper: Tandai job sedang dikirim; false jika sudah diambil proses lain
+func claim(job *models.NotificationJob) bool {
+	res := database.DB.Model(&models.NotificationJob{}).
+		Where("id = ? AND status = ?", job.ID, "PENDING").
+		Update("status", "SENDING")
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false
+	}
+	job.Status = "SENDING"
+	return true
+}
+
+// Helper: Kembalikan job SENDING yang tertinggal ke PENDING agar dicoba ulang
+func releaseStaleClaims() int64 {
+	res := database.DB.Model(&models.NotificationJob{}).
+		Where("status = ? AND updated_at < ?", "SENDING", time.Now().Add(-ClaimTimeout)).
+		Updates(map[string]interface{}{"status": "PENDING", "next_attempt_at": time.Now()})
+	if res.Error != nil {
+		log.Printf("[NOTIFY] Gagal melepas job SENDING tertinggal: %v\n", res.Error)
+		return 0
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("[NOTIFY] %d job SENDING tertinggal dikembalikan ke PENDING\n", res.RowsAffected)
+	}
+	return res.RowsAffected
+}
+
+// Helper: Buat job untuk semua preferensi yang cocok
+func enqueueForPrefs(code string, prefs []models.NotificationPreference, data map[string]interface{}) int {
+	count := 0
+	for _, p := range prefs {
+		if !subscribed(p, code) {
+			continue
+		}
+		if _, err := Enqueue(code, p.NIK, p.Channel, resolveTarget(p), data); err != nil {
+			log.Printf("[NOTIFY] Gagal enqueue %s untuk %s: %v\n", code, p.NIK, err)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// NotifyUsers mengirim event ke user tertentu (berdasarkan NIK)
+func NotifyUsers(code string, niks []string, data map[string]interface{}) int {
+	if len(niks) == 0 {
+		return 0
+	}
+	var prefs []models.NotificationPreference
+	database.DB.Where("nik IN ? AND aktif = ?", niks, true).Find(&prefs)
+	return enqueueForPrefs(code, prefs, data)
+}
+
+// NotifyRoles mengirim event ke semua user dengan role tertentu
+func NotifyRoles(code string, roles []string, data map[string]interface{}) int {
+	if len(roles) == 0 {
+		return 0
+	}
+	var prefs []models.NotificationPreference
+	database.DB.Where("role IN ? AND aktif = ?", roles, true).Find(&prefs)
+	return enqueueForPrefs(code, prefs, data)
+}
+
+// Helper: Jeda sebelum percobaan berikutnya (1, 2, 4, 8 menit)
+func backoff(attempts int) time.Duration {
+	return time.Duration(1<<(attempts-1)) * time.Minute
+}
+
+// Deliver mencoba mengirim satu job dan mengatur retry dengan backoff eksponensial
+func Deliver(job *models.NotificationJob) error {
+	ch, ok := Channels[job.Channel]
+	var err error
+	if !ok {
+		err = fmt.Errorf("channel %s tidak dikenal", job.Channel)
+	} else {
+		err = ch.Send(job.Target, job.Subject, job.Body)
+	}
+
+	job.Attempts++
+	if err == nil {
+		now := time.Now()
+		job.Status = "SENT"
+		job.SentAt = &now
+		job.LastError = ""
+	} else {
+		job.LastError = err.Error()
+		if job.Attempts >= MaxAttempts || !ok {
+			job.Status = "FAILED"
+		} else {
+			job.Status = "PENDING"
+			job.NextAttemptAt = time.Now().Add(backoff(job.Attempts))
+		}
+	}
+	database.DB.Save(job)
+	return err
+}
+
+// ProcessQueue mengirim semua job PENDING yang sudah waktunya
+func ProcessQueue() (sent, failed int) {
+	releaseStaleClaims()
+
+	var jobs []models.NotificationJob
+	database.DB.Where("status = ? AND next_attempt_at <= ?", "PENDING", time.Now()).
+		Order("next_attempt_at asc").Limit(50).Find(&jobs)
+
+	for i := range jobs {
+		if !claim(&jobs[i]) {
+			continue
+		}
+		if err := Deliver(&jobs[i]); err != nil {
+			log.Printf("[NOTIFY] Job #%d gagal (percobaan %d): %v\n", jobs[i].ID, jobs[i].Attempts, err)
+			failed++
+		} else {
+			sent++
+		}
+	}
+	return sent, failed
+}
+
+// StartWorker memproses antrian secara berkala (dipanggil dari main sebagai goroutine)
+func StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ProcessQueue()
+	}
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"factory-api/database"
+	"factory-api/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Helper: Database SQLite in-memory untuk satu test
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gagal buka sqlite: %v", err)
+	}
+	// Satu koneksi saja agar semua query melihat database in-memory yang sama
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.NotificationJob{}, &models.NotificationTemplate{}); err != nil {
+		t.Fatalf("gagal migrasi: %v", err)
+	}
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = prev })
+}
+
+// Helper: Server webhook tiruan yang membalas status tertentu dan menghitung request
+func webhookServer(t *testing.T, status int) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("NOTIFY_WEBHOOK_HOSTS", "127.0.0.1")
+	return srv, &hits
+}
+
+func TestRenderDefaultTemplate(t *testing.T) {
+	setupTestDB(t)
+
+	subject, body, err := render("repair.opened", map[string]interface{}{
+		"id": 7, "no_mc": "PRS-01", "symptom": "Oli bocor", "reported_by": "1001", "reported_at": "2026-10-19 08:00",
+	})
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	if subject != "[REPAIR] Mesin PRS-01 rusak - tiket #7" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Gejala: Oli bocor") || !strings.Contains(body, "oleh 1001") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestRenderDatabaseTemplateOverridesDefault(t *testing.T) {
+	setupTestDB(t)
+	database.DB.Create(&models.NotificationTemplate{Code: "test", Subject: "Tes {{.target}}", Body: "Isi {{.kosong}}"})
+
+	subject, body, err := render("test", map[string]interface{}{"target": "ops"})
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	if subject != "Tes ops" {
+		t.Errorf("subject = %q", subject)
+	}
+	// Key yang tidak ada tidak membuat render gagal
+	if !strings.HasPrefix(body, "Isi") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestRenderInvalidTemplate(t *testing.T) {
+	setupTestDB(t)
+	database.DB.Create(&models.NotificationTemplate{Code: "rusak", Subject: "{{.a", Body: "x"})
+
+	if _, _, err := render("rusak", nil); err == nil {
+		t.Fatal("template rusak seharusnya error")
+	}
+	if _, err := Enqueue("rusak", "1001", "webhook", "http://127.0.0.1", nil); err == nil {
+		t.Fatal("Enqueue dengan template rusak seharusnya error")
+	}
+}
+
+func TestDeliverSuccess(t *testing.T) {
+	setupTestDB(t)
+	srv, hits := webhookServer(t, http.StatusOK)
+
+	job, err := Enqueue("test", "1001", "webhook", srv.URL, map[string]interface{}{"target": srv.URL})
+	if err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+	if err := Deliver(job); err != nil {
+		t.Fatalf("deliver error: %v", err)
+	}
+	if job.Status != "SENT" || job.SentAt == nil || job.Attempts != 1 {
+		t.Errorf("job = %+v", job)
+	}
+	if *hits != 1 {
+		t.Errorf("hits = %d, seharusnya 1", *hits)
+	}
+}
+
+func TestDeliverRetryBackoff(t *testing.T) {
+	setupTestDB(t)
+	srv, hits := webhookServer(t, http.StatusInternalServerError)
+
+	job, err := Enqueue("test", "1001", "webhook", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("enqueue error: %v", err)
+	}
+
+	for attempt := 1; attempt < MaxAttempts; attempt++ {
+		before := time.Now()
+		if err := Deliver(job); err == nil {
+			t.Fatalf("percobaan %d seharusnya gagal", attempt)
+		}
+		if job.Status != "PENDING" || job.Attempts != attempt {
+			t.Fatalf("percobaan %d: status %s attempts %d", attempt, job.Status, job.Attempts)
+		}
+		want := before.Add(backoff(attempt))
+		if job.NextAttemptAt.Before(want) || job.NextAttemptAt.After(want.Add(time.Second)) {
+			t.Errorf("percobaan %d: next attempt %v, seharusnya sekitar %v", attempt, job.NextAttemptAt, want)
+		}
+	}
+
+	if err := Deliver(job); err == nil {
+		t.Fatal("percobaan terakhir seharusnya gagal")
+	}
+	if job.Status != "FAILED" || job.Attempts != MaxAttempts {
+		t.Errorf("setelah %d percobaan: status %s attempts %d", MaxAttempts, job.Status, job.Attempts)
+	}
+	if int(*hits) != MaxAttempts {
+		t.Errorf("hits = %d, seharusnya %d", *hits, MaxAttempts)
+	}
+}
+
+func TestBackoffDoubles(t *testing.T) {
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for i, w := range want {
+		if got := backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, seharusnya %v", i+1, got, w)
+		}
+	}
+}
+
+func TestProcessQueueSkipsClaimedAndFuture(t *testing.T) {
+	setupTestDB(t)
+	srv, hits := webhookServer(t, http.StatusOK)
+
+	due, _ := Enqueue("test", "1001", "webhook", srv.URL, nil)
+	future, _ := Enqueue("test", "1002", "webhook", srv.URL, nil)
+	database.DB.Model(future).Update("next_attempt_at", time.Now().Add(time.Hour))
+
+	// Job yang dikirim langsung tidak boleh ikut diambil worker
+	if _, err := SendNow("test", "1003", "webhook", srv.URL, nil); err != nil {
+		t.Fatalf("SendNow error: %v", err)
+	}
+
+	sent, failed := ProcessQueue()
+	if sent != 1 || failed != 0 {
+		t.Errorf("sent %d failed %d, seharusnya 1 dan 0", sent, failed)
+	}
+	if *hits != 2 {
+		t.Errorf("hits = %d, seharusnya 2 (SendNow + satu job jatuh tempo)", *hits)
+	}
+
+	var reloaded models.NotificationJob
+	database.DB.First(&reloaded, due.ID)
+	if reloaded.Status != "SENT" {
+		t.Errorf("job jatuh tempo berstatus %s", reloaded.Status)
+	}
+	if claim(&reloaded) {
+		t.Error("job yang sudah SENT tidak boleh bisa di-claim")
+	}
+}
+
+func TestProcessQueueReleasesStaleClaim(t *testing.T) {
+	setupTestDB(t)
+	srv, hits := webhookServer(t, http.StatusOK)
+
+	stale, _ := Enqueue("test", "1001", "webhook", srv.URL, nil)
+	fresh, _ := SendNow("test", "1002", "webhook", srv.URL, nil)
+	*hits = 0
+
+	// Simulasi proses mati setelah claim: status SENDING dan tidak pernah diperbarui lagi
+	database.DB.Model(&models.NotificationJob{}).Where("id = ?", stale.ID).
+		UpdateColumns(map[string]interface{}{"status": "SENDING", "updated_at": time.Now().Add(-2 * ClaimTimeout)})
+	database.DB.Model(&models.NotificationJob{}).Where("id = ?", fresh.ID).
+		UpdateColumn("status", "SENDING")
+
+	sent, _ := ProcessQueue()
+	if sent != 1 || *hits != 1 {
+		t.Errorf("sent %d hits %d, seharusnya hanya job tertinggal yang dikirim ulang", sent, *hits)
+	}
+
+	var reloaded models.NotificationJob
+	database.DB.First(&reloaded, stale.ID)
+	if reloaded.Status != "SENT" {
+		t.Errorf("job tertinggal berstatus %s, seharusnya SENT", reloaded.Status)
+	}
+	var claimed models.NotificationJob
+	database.DB.First(&claimed, fresh.ID)
+	if claimed.Status != "SENDING" {
+		t.Errorf("job yang baru di-claim berstatus %s, seharusnya tetap SENDING", claimed.Status)
+	}
+}
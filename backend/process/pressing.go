@@ -0,0 +1,52 @@
+package process
+
+// Proses pressing bawaan: data LWP dari vtrx_lwp_prs
+var pressing = Definition{
+	Code:  "PRS",
+	Name:  "Pressing",
+	Table: "vtrx_lwp_prs",
+	Columns: Columns{
+		Tanggal:  "tanggal",
+		NoMC:     "noMC",
+		Proses:   "proses",
+		ItemCode: "itemCode",
+		MoldCode: "moldCode",
+		Mulai:    "MULAI",
+		Selesai:  "SELESAI",
+		Total:    "Total",
+		OK:       "OK",
+		NG:       "NG",
+		Operator: "nama",
+		NPK:      "NPK",
+		LotNo:    "lotNo",
+		Shift:    "shift",
+		Jam:      "jam",
+	},
+	Defects: []Defect{
+		{"BINTIK", "Bintik", "Bintik"},
+		{"TNGISI", "Tidak Ngisi", "TNgisi"},
+		{"LENGKET", "Lengket", "Lengket"},
+		{"DEFORM", "Deform", "Deform"},
+		{"MENTAH", "Mentah", "Mentah"},
+		{"RETAK", "Retak", "Retak"},
+		{"ROBEK", "Robek", "Robek"},
+		{"KBODY", "K-Body", "KBody"},
+		{"KOTOR", "Kotor", "Kotor"},
+		{"CCAVITY", "C-Cavity", "CCavity"},
+		{"KARAT", "Karat", "Karat"},
+		{"CMETAL", "C-Metal", "CMetal"},
+		{"ANGIN", "Angin", "Angin"},
+		{"RUNNER", "Runner", "Runner"},
+		{"BONDING", "Bonding", "Bonding"},
+		{"DIMENSI", "Dimensi", "Dimensi"},
+		{"HARDNESS", "Hardness", "Hardness"},
+		{"BLOMING", "Bloming", "Bloming"},
+		{"SALAHSLIT", "Salah Slit", "SalahSlit"},
+		{"CHAMPER", "Champer", "Champer"},
+		{"MTLKELIHATAN", "Material Kelihatan", "MtlKelihatan"},
+		{"BURRY", "Burry", "Burry"},
+		{"MIRING", "Miring", "Miring"},
+		{"MAMPET", "Mampet", "Mampet"},
+		{"LAIN2", "Lain-lain", "lain2"},
+	},
+}
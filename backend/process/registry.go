@@ -0,0 +1,199 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kolom sumber data satu proses. Nilai adalah nama kolom di tabel/view MySQL;
+// kosong = tidak ada (diisi default saat dinormalisasi).
+type Columns struct {
+	Tanggal  string `json:"tanggal"`
+	NoMC     string `json:"no_mc"`
+	Proses   string `json:"proses"` // Kolom label sub-proses; kosong = pakai kode proses
+	ItemCode string `json:"item_code"`
+	MoldCode string `json:"mold_code"`
+	Mulai    string `json:"mulai"`
+	Selesai  string `json:"selesai"`
+	Total    string `json:"total"` // Kosong = OK + NG
+	OK       string `json:"ok"`
+	NG       string `json:"ng"`
+	Operator string `json:"operator"`
+	NPK      string `json:"npk"`
+	LotNo    string `json:"lot_no"`
+	Shift    string `json:"shift"`
+	Jam      string `json:"jam"` // Jam kerja; kosong = dihitung dari MULAI - SELESAI
+}
+
+// Jenis reject bawaan per kolom sumber data (dipakai mengisi katalog reject saat seeding)
+type Defect struct {
+	Code   string `json:"code"`
+	Label  string `json:"label"`
+	Column string `json:"column"`
+}
+
+// Definisi satu proses produksi (CUT, PRS, FIN, ...)
+type Definition struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Table   string   `json:"table"` // Tabel / view MySQL
+	Columns Columns  `json:"columns"`
+	Defects []Defect `json:"defects"`
+}
+
+// Identifier SQL yang diizinkan (nama tabel/kolom di-interpolasi ke query)
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Definition{}
+)
+
+func init() {
+	Register(pressing)
+}
+
+// Register menambah / mengganti definisi proses (berdasarkan Code)
+func Register(def Definition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	registry[def.Code] = def
+	mu.Unlock()
+	return nil
+}
+
+// Get mengambil definisi proses berdasarkan kode
+func Get(code string) (Definition, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	def, ok := registry[strings.ToUpper(code)]
+	return def, ok
+}
+
+// All mengembalikan semua proses terdaftar (urut kode)
+func All() []Definition {
+	mu.RLock()
+	defer mu.RUnlock()
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+	return defs
+}
+
+// Load membaca definisi tambahan dari file JSON (array Definition).
+// File tidak ada bukan error: hanya proses bawaan yang aktif.
+// Contoh CUT & FIN ada di processes.example.json: salin ke processes.json lalu sesuaikan nama view, kolom
+// dan kolom reject dengan view LWP di ERP (kolom kosong = tidak ada, lihat Columns).
+func Load(path string) error {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return fmt.Errorf("format %s salah: %w", path, err)
+	}
+	for _, def := range defs {
+		def.Code = strings.ToUpper(def.Code)
+		if err := Register(def); err != nil {
+			return fmt.Errorf("proses %s: %w", def.Code, err)
+		}
+	}
+	return nil
+}
+
+// Validate memastikan tabel & kolom wajib terisi dan aman dipakai di SQL
+func (d Definition) Validate() error {
+	if d.Code == "" || d.Table == "" {
+		return fmt.Errorf("code dan table wajib diisi")
+	}
+	required := map[string]string{
+		"tanggal": d.Columns.Tanggal, "no_mc": d.Columns.NoMC, "item_code": d.Columns.ItemCode,
+		"mulai": d.Columns.Mulai, "selesai": d.Columns.Selesai, "ok": d.Columns.OK, "ng": d.Columns.NG,
+	}
+	for name, col := range required {
+		if col == "" {
+			return fmt.Errorf("kolom %s wajib diisi", name)
+		}
+	}
+
+	idents := []string{d.Table, d.Columns.Tanggal, d.Columns.NoMC, d.Columns.Proses, d.Columns.ItemCode,
+		d.Columns.MoldCode, d.Columns.Mulai, d.Columns.Selesai, d.Columns.Total, d.Columns.OK, d.Columns.NG,
+		d.Columns.Operator, d.Columns.NPK, d.Columns.LotNo, d.Columns.Shift, d.Columns.Jam}
+	for _, def := range d.Defects {
+		idents = append(idents, def.Column)
+	}
+	for _, ident := range idents {
+		if ident != "" && !identifierPattern.MatchString(ident) {
+			return fmt.Errorf("nama tabel/kolom tidak valid: %q", ident)
+		}
+	}
+	if !identifierPattern.MatchString(d.Code) {
+		return fmt.Errorf("kode proses tidak valid: %q", d.Code)
+	}
+	return nil
+}
+
+// Helper: Referensi kolom, atau nilai default jika kolom tidak ada
+func column(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return "`" + name + "`"
+}
+
+// Nama kolom hasil Source (tidak boleh dipakai ulang oleh kolom tambahan SourceWith)
+var sourceColumns = map[string]bool{
+	"tanggal": true, "nomc": true, "proses": true, "itemcode": true, "moldcode": true, "mulai": true,
+	"selesai": true, "total": true, "ok": true, "ng": true, "nama": true, "npk": true, "lotno": true,
+	"shift": true, "jam": true, "process_code": true,
+}
+
+// Source mengembalikan derived table dengan nama kolom seragam (format vtrx_lwp_prs):
+// tanggal, noMC, proses, itemCode, moldCode, MULAI, SELESAI, Total, OK, NG, nama, NPK, lotNo, shift, jam, process_code.
+// Dipakai sebagai "FROM <Source> t" sehingga query chart berlaku untuk semua proses.
+func (d Definition) Source() string {
+	return d.SourceWith()
+}
+
+// SourceWith sama dengan Source, ditambah kolom mentah tabel proses (misal kolom reject dari katalog)
+func (d Definition) SourceWith(extra ...string) string {
+	c := d.Columns
+	total := column(c.Total, "(COALESCE(`"+c.OK+"`, 0) + COALESCE(`"+c.NG+"`, 0))")
+	fields := fmt.Sprintf("%s AS tanggal, %s AS noMC, %s AS proses, %s AS itemCode, %s AS moldCode, "+
+		"%s AS MULAI, %s AS SELESAI, %s AS Total, %s AS OK, %s AS NG, %s AS nama, %s AS NPK, %s AS lotNo, "+
+		"%s AS shift, %s AS jam, '%s' AS process_code",
+		column(c.Tanggal, "NULL"), column(c.NoMC, "''"), column(c.Proses, "'"+d.Code+"'"),
+		column(c.ItemCode, "''"), column(c.MoldCode, "''"), column(c.Mulai, "NULL"), column(c.Selesai, "NULL"),
+		total, column(c.OK, "0"), column(c.NG, "0"), column(c.Operator, "''"), column(c.NPK, "''"),
+		column(c.LotNo, "''"), column(c.Shift, "''"), column(c.Jam, "NULL"), d.Code)
+	for _, col := range extra {
+		if IsIdentifier(col) && !sourceColumns[strings.ToLower(col)] {
+			fields += ", `" + col + "`"
+		}
+	}
+	return fmt.Sprintf("(SELECT %s FROM `%s`)", fields, d.Table)
+}
+
+// IsIdentifier: apakah nama aman di-interpolasi ke SQL sebagai nama tabel/kolom
+func IsIdentifier(name string) bool {
+	return identifierPattern.MatchString(name)
+}
+
+// HasLabel: apakah label proses (hasil kolom proses) mungkin berasal dari definisi ini
+func (d Definition) HasLabel(label string) bool {
+	return d.Columns.Proses != "" || strings.EqualFold(label, d.Code)
+}
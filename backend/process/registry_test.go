@@ -0,0 +1,127 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Bintik", true},
+		{"lain2", true},
+		{"_kolom", true},
+		{"", false},
+		{"2kolom", false},
+		{"Bintik`", false},
+		{"a b", false},
+		{"OK; DROP TABLE x", false},
+		{"kolom-reject", false},
+		{"t.kolom", false},
+	}
+	for _, tt := range tests {
+		if got := IsIdentifier(tt.name); got != tt.want {
+			t.Errorf("IsIdentifier(%q) = %v, seharusnya %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSourceWithExtraColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   []string
+		want    []string // harus muncul di query
+		notWant []string // tidak boleh muncul di query
+	}{
+		{"tanpa kolom tambahan", nil, []string{"FROM `vtrx_lwp_prs`"}, []string{", `Bintik`"}},
+		{"kolom reject valid", []string{"Bintik", "TNgisi"}, []string{", `Bintik`", ", `TNgisi`"}, nil},
+		{"identifier tidak valid diabaikan", []string{"Bintik`) x; --", "a b", ""}, nil, []string{"x; --", "`a b`", "``"}},
+		{"nama kolom hasil Source tidak diulang", []string{"OK", "noMC", "jam", "PROCESS_CODE"}, nil,
+			[]string{"`OK`,", "`noMC`,", "`jam`,", "`PROCESS_CODE`"}},
+	}
+	base := pressing.Source()
+	for _, tt := range tests {
+		got := pressing.SourceWith(tt.extra...)
+		if len(tt.want) == 0 && got != base {
+			t.Errorf("%s: query berubah: %s", tt.name, got)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(got, s) {
+				t.Errorf("%s: query tidak memuat %q: %s", tt.name, s, got)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(got, s) {
+				t.Errorf("%s: query memuat %q: %s", tt.name, s, got)
+			}
+		}
+	}
+}
+
+func TestSourceDefaultsForMissingColumns(t *testing.T) {
+	def := Definition{Code: "CUT", Table: "vtrx_lwp_cut", Columns: Columns{
+		Tanggal: "tgl", NoMC: "mesin", ItemCode: "item", Mulai: "mulai", Selesai: "selesai", OK: "ok", NG: "ng",
+	}}
+	got := def.Source()
+	for _, s := range []string{
+		"'CUT' AS proses",
+		"(COALESCE(`ok`, 0) + COALESCE(`ng`, 0)) AS Total",
+		"NULL AS jam",
+		"'' AS lotNo",
+		"'CUT' AS process_code",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("Source() tidak memuat %q: %s", s, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() Definition {
+		return Definition{Code: "CUT", Table: "vtrx_lwp_cut", Columns: Columns{
+			Tanggal: "tgl", NoMC: "mesin", ItemCode: "item", Mulai: "mulai", Selesai: "selesai", OK: "ok", NG: "ng",
+		}}
+	}
+	tests := []struct {
+		name   string
+		modify func(d *Definition)
+		ok     bool
+	}{
+		{"definisi lengkap", func(d *Definition) {}, true},
+		{"pressing bawaan", func(d *Definition) { *d = pressing }, true},
+		{"tanpa table", func(d *Definition) { d.Table = "" }, false},
+		{"kolom wajib kosong", func(d *Definition) { d.Columns.NG = "" }, false},
+		{"nama table tidak valid", func(d *Definition) { d.Table = "lwp; DROP TABLE x" }, false},
+		{"kolom opsional tidak valid", func(d *Definition) { d.Columns.LotNo = "lot`no" }, false},
+		{"kolom reject tidak valid", func(d *Definition) { d.Defects = []Defect{{"X", "X", "a b"}} }, false},
+		{"kode proses tidak valid", func(d *Definition) { d.Code = "CUT'" }, false},
+	}
+	for _, tt := range tests {
+		d := valid()
+		tt.modify(&d)
+		if err := d.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestHasLabel(t *testing.T) {
+	cut := Definition{Code: "CUT"}
+	tests := []struct {
+		def   Definition
+		label string
+		want  bool
+	}{
+		{cut, "CUT", true},
+		{cut, "cut", true},
+		{cut, "PRS", false},
+		{pressing, "PRS-A", true}, // Label dari kolom proses bisa apa saja
+	}
+	for _, tt := range tests {
+		if got := tt.def.HasLabel(tt.label); got != tt.want {
+			t.Errorf("%s.HasLabel(%q) = %v, seharusnya %v", tt.def.Code, tt.label, got, tt.want)
+		}
+	}
+}
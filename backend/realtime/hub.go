@@ -0,0 +1,124 @@
+package realtime
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Jenis event yang dikirim ke dashboard
+const (
+	EventMachineState = "machine.state"
+	EventLWPCreated   = "lwp.created"
+	EventCycle        = "cycle.recorded"
+	EventWorkOrder    = "work_order.transition"
+	EventRejectSpike  = "reject.spike"
+	EventAlert        = "alert"
+)
+
+// Event yang di-broadcast ke subscriber
+type Event struct {
+	ID     uint64      `json:"id"`
+	Type   string      `json:"type"`
+	Proses string      `json:"proses,omitempty"` // CUT, PRS, FIN (kosong = semua proses)
+	NoMC   string      `json:"no_mc,omitempty"`
+	Roles  []string    `json:"-"` // Kosong = semua role boleh menerima
+	Data   interface{} `json:"data"`
+	At     time.Time   `json:"at"`
+}
+
+// Filter langganan per koneksi
+type Filter struct {
+	Role   string
+	Proses string          // Kosong = semua proses
+	Types  map[string]bool // Kosong = semua jenis event
+}
+
+// Match mengecek apakah event boleh & perlu dikirim ke subscriber dengan filter ini
+func (f Filter) Match(e Event) bool {
+	if len(e.Roles) > 0 {
+		allowed := false
+		for _, r := range e.Roles {
+			if r == f.Role {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if f.Proses != "" && e.Proses != "" && f.Proses != e.Proses {
+		return false
+	}
+	if len(f.Types) > 0 && !f.Types[e.Type] {
+		return false
+	}
+	return true
+}
+
+// Subscriber = satu koneksi SSE
+type Subscriber struct {
+	C      chan Event
+	filter Filter
+}
+
+// Hub publish/subscribe sederhana di memori
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[*Subscriber]struct{}
+	nextID uint64
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscriber]struct{})}
+}
+
+// Hub global yang dipakai controller
+var Default = NewHub()
+
+// Subscribe mendaftarkan koneksi baru. Jangan lupa Unsubscribe saat koneksi ditutup.
+func (h *Hub) Subscribe(f Filter) *Subscriber {
+	s := &Subscriber{C: make(chan Event, 32), filter: f}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.C)
+	}
+	h.mu.Unlock()
+}
+
+// Publish mengirim event ke semua subscriber yang cocok.
+// Subscriber yang lambat (buffer penuh) dilewati supaya request produksi tidak ikut tertahan.
+func (h *Hub) Publish(e Event) {
+	e.ID = atomic.AddUint64(&h.nextID, 1)
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+		}
+	}
+}
+
+// Count jumlah koneksi aktif
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}